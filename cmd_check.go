@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/check"
+	"github.com/jasonwillschiu/codebrev/internal/parser"
+	"github.com/jasonwillschiu/codebrev/internal/writer"
+)
+
+// runCheckCommand implements `codebrev check [DIRECTORY]`: regenerate the
+// markdown outline in memory and compare it with the committed one. It
+// exits 1 when the committed outline is missing, stale or hand-edited.
+func runCheckCommand(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	var (
+		file       = fs.String("file", "", "Committed outline to check (defaults to the config output or 'codebrev.md' in DIRECTORY)")
+		configFile = fs.String("config", "", "Config file (defaults to .codebrev.yaml in DIRECTORY, if present)")
+		cacheDir   = fs.String("cache-dir", "", "Reuse per-file parse results stored in this directory")
+		workers    = fs.Int("workers", runtime.NumCPU(), "Number of files parsed concurrently")
+		maxTokens  = fs.Int("max-tokens", 0, "Token budget the outline was generated with")
+		docs       = fs.String("docs", "", "Doc comment mode the outline was generated with: first, full or none")
+		analysis   = fs.String("analysis", "", "Go analysis mode the outline was generated with: syntax or types")
+	)
+	var includes, excludes stringList
+	fs.Var(&includes, "include", "Only parse files matching this gitignore-style `pattern` (repeatable)")
+	fs.Var(&excludes, "exclude", "Skip files and directories matching this gitignore-style `pattern` (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "USAGE:")
+		fmt.Fprintln(os.Stderr, "  codebrev check [OPTIONS] [DIRECTORY]")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "Regenerate the markdown outline for DIRECTORY in memory and compare it with the committed")
+		fmt.Fprintln(os.Stderr, "file. Exits 1 and lists the differing sections when the committed outline is out of date.")
+		fmt.Fprintln(os.Stderr, "Pass the same --include/--exclude/--max-tokens/--analysis/--docs flags used to generate it.")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "OPTIONS:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	if *maxTokens < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --max-tokens must be positive")
+		return 2
+	}
+	if !writer.IsDocsMode(*docs) {
+		fmt.Fprintf(os.Stderr, "Error: unknown docs mode %q (expected %q, %q or %q)\n", *docs, writer.DocsFirst, writer.DocsFull, writer.DocsNone)
+		return 2
+	}
+	if !parser.IsAnalysis(*analysis) {
+		fmt.Fprintf(os.Stderr, "Error: unknown analysis mode %q (expected %q or %q)\n", *analysis, parser.AnalysisSyntax, parser.AnalysisTypes)
+		return 2
+	}
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "Error: directory does not exist: %s\n", root)
+		return 1
+	}
+
+	cfg, err := loadProjectConfig(root, *configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid config: %v\n", err)
+		return 1
+	}
+	opts := cliOptions{
+		Format:    formatMarkdown,
+		Config:    cfg,
+		Include:   includes,
+		Exclude:   excludes,
+		CacheDir:  *cacheDir,
+		Workers:   *workers,
+		MaxTokens: *maxTokens,
+		Analysis:  *analysis,
+		Docs:      *docs,
+	}
+
+	path := *file
+	if path == "" {
+		path = cfg.OutputPath()
+	}
+	if path == "" {
+		path = filepath.Join(root, defaultOutputName(formatMarkdown))
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		fmt.Fprintf(os.Stderr, "Error: %s: only markdown outlines can be checked\n", path)
+		return 2
+	}
+
+	committed, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fmt.Printf("%s does not exist. Run `codebrev` to generate it.\n", path)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	out, err := loadOutline(root, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	var generated bytes.Buffer
+	if err := writer.WriteOutlineWithOptions(&generated, out, opts.writerOptions()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	result := check.Compare(committed, generated.Bytes())
+	if result.Current {
+		fmt.Printf("%s is up to date.\n", path)
+		return 0
+	}
+	writeCheckSummary(path, result)
+	return 1
+}
+
+func writeCheckSummary(path string, r *check.Result) {
+	fmt.Printf("%s is out of date.\n", path)
+	switch {
+	case !r.HasHeader:
+		fmt.Println("It has no codebrev header; it was written by an older version or by hand.")
+	case r.Edited:
+		fmt.Println("It was edited after it was generated (content does not match its fingerprint).")
+	}
+	if r.HasHeader && r.CommittedVersion != r.GeneratedVersion {
+		fmt.Printf("Generated by codebrev %s; this is codebrev %s.\n", r.CommittedVersion, r.GeneratedVersion)
+	}
+
+	if len(r.Differences) > 0 {
+		fmt.Printf("\n%d section(s) differ:\n", len(r.Differences))
+		for _, d := range r.Differences {
+			fmt.Printf("  %-8s %s\n", d.Change+":", d.Section)
+		}
+	}
+	fmt.Println("\nRun `codebrev` to regenerate it.")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jasonwillschiu/codebrev/internal/impact"
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// runImpactCommand implements `codebrev impact [OPTIONS] PATH...`.
+func runImpactCommand(args []string) int {
+	fs := flag.NewFlagSet("impact", flag.ContinueOnError)
+	var (
+		root     = fs.String("root", ".", "Scan root directory")
+		format   = fs.String("format", "text", "Output format: text or json")
+		fromJSON = fs.String("from-json", "", "Use a previously generated JSON outline instead of parsing")
+		since    = fs.String("since", "", "Report on files changed between `REF` and HEAD (git diff REF...HEAD)")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "USAGE:")
+		fmt.Fprintln(os.Stderr, "  codebrev impact [OPTIONS] PATH...")
+		fmt.Fprintln(os.Stderr, "  codebrev impact [OPTIONS] --since REF")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "Print the files and packages affected by changing each PATH (a file or package directory),")
+		fmt.Fprintln(os.Stderr, "or by everything changed on the current branch since REF.")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "OPTIONS:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "text" && *format != formatJSON {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (expected \"text\" or %q)\n", *format, formatJSON)
+		return 2
+	}
+
+	targets := fs.Args()
+	if *since != "" && len(targets) > 0 {
+		fmt.Fprintln(os.Stderr, "Error: --since cannot be combined with explicit paths")
+		return 2
+	}
+	if *since == "" && len(targets) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := loadProjectConfig(*root, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid config: %v\n", err)
+		return 1
+	}
+
+	out, err := loadOutline(*root, cliOptions{FromJSON: *fromJSON, Config: cfg})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *since != "" {
+		return writeChangeImpact(out, *root, *since, *format)
+	}
+
+	report, err := impact.Analyze(out, targets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *format == formatJSON {
+		err = impact.WriteJSON(os.Stdout, report)
+	} else {
+		err = impact.WriteText(os.Stdout, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeChangeImpact reports on the files changed since ref.
+func writeChangeImpact(out *outline.Outline, root, ref, format string) int {
+	changed, err := impact.GitChangedFiles(root, ref)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	report := impact.AnalyzeChanges(out, changed)
+	report.Since = ref
+
+	if format == formatJSON {
+		err = impact.WriteJSON(os.Stdout, report)
+	} else {
+		err = impact.WriteChangesText(os.Stdout, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jasonwillschiu/codebrev/internal/config"
+	"github.com/jasonwillschiu/codebrev/internal/layering"
+)
+
+// runLintArchCommand implements `codebrev lint-arch [DIRECTORY]`: check the
+// package graph against the architecture rules in the project config. It
+// exits 1 when any rule is violated.
+func runLintArchCommand(args []string) int {
+	fs := flag.NewFlagSet("lint-arch", flag.ContinueOnError)
+	var (
+		configFile = fs.String("config", "", "Config file (defaults to .codebrev.yaml in DIRECTORY, if present)")
+		format     = fs.String("format", "text", "Output format: text or json")
+		fromJSON   = fs.String("from-json", "", "Use a previously generated JSON outline instead of parsing")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "USAGE:")
+		fmt.Fprintln(os.Stderr, "  codebrev lint-arch [OPTIONS] [DIRECTORY]")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "Check package dependencies in DIRECTORY against the architecture.rules in the project")
+		fmt.Fprintln(os.Stderr, "config. Exits 1 and lists each forbidden dependency when any rule is violated.")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "OPTIONS:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "text" && *format != formatJSON {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (expected \"text\" or %q)\n", *format, formatJSON)
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	cfg, err := loadProjectConfig(root, *configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid config: %v\n", err)
+		return 1
+	}
+	if cfg == nil || len(cfg.Architecture.Rules) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no architecture.rules configured (add them to %s)\n", config.FileName)
+		return 2
+	}
+
+	out, err := loadOutline(root, cliOptions{FromJSON: *fromJSON, Config: cfg})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	violations := layering.Check(out, cfg.Architecture.Rules)
+	if *format == formatJSON {
+		err = layering.WriteJSON(os.Stdout, violations)
+	} else {
+		err = layering.WriteText(os.Stdout, violations)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(violations) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jasonwillschiu/codebrev/internal/treasuremap"
+)
+
+// runMapCommand implements `codebrev map --seed FILE [--seed FILE]...`, the
+// treasure map described in docs/skill-treasure-map.md.
+func runMapCommand(args []string) int {
+	fs := flag.NewFlagSet("map", flag.ContinueOnError)
+	var seeds stringList
+	fs.Var(&seeds, "seed", "File or package directory you expect to change (repeatable)")
+	var (
+		root     = fs.String("root", ".", "Scan root directory")
+		nodes    = fs.Int("nodes", treasuremap.DefaultNodes, "Maximum nodes in the map, seeds included")
+		edges    = fs.Int("edges", treasuremap.DefaultEdges, "Maximum edges in the diagram")
+		hops     = fs.Int("hops", treasuremap.DefaultHops, "Maximum distance from a seed")
+		format   = fs.String("format", formatMarkdown, "Output format: markdown or json")
+		output   = fs.String("output", "", "Write the map to this file instead of stdout")
+		fromJSON = fs.String("from-json", "", "Use a previously generated JSON outline instead of parsing")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "USAGE:")
+		fmt.Fprintln(os.Stderr, "  codebrev map [OPTIONS] --seed PATH [--seed PATH]...")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "Build a small treasure map around the files you plan to change: their dependencies,")
+		fmt.Fprintln(os.Stderr, "dependents, shared types and packages, ranked and capped to the node and edge budgets.")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "OPTIONS:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != formatMarkdown && *format != formatJSON {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (expected %q or %q)\n", *format, formatMarkdown, formatJSON)
+		return 2
+	}
+	if len(seeds) == 0 || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if *nodes <= 0 || *edges <= 0 || *hops <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --nodes, --edges and --hops must be positive")
+		return 2
+	}
+
+	cfg, err := loadProjectConfig(*root, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid config: %v\n", err)
+		return 1
+	}
+	out, err := loadOutline(*root, cliOptions{FromJSON: *fromJSON, Config: cfg})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	m, err := treasuremap.Build(out, seeds, treasuremap.Options{Nodes: *nodes, Edges: *edges, Hops: *hops})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	var buf bytes.Buffer
+	if *format == formatJSON {
+		err = treasuremap.WriteJSON(&buf, m)
+	} else {
+		err = treasuremap.WriteMarkdown(&buf, m)
+	}
+	if err == nil {
+		if *output != "" {
+			err = os.WriteFile(*output, buf.Bytes(), 0o644)
+		} else {
+			_, err = os.Stdout.Write(buf.Bytes())
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
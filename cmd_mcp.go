@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jasonwillschiu/codebrev/internal/mcp"
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// runMCPCommand implements `codebrev mcp [DIRECTORY]`: an MCP server on
+// stdin/stdout. Nothing but protocol messages may be written to stdout.
+func runMCPCommand(args []string) int {
+	fs := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "USAGE:")
+		fmt.Fprintln(os.Stderr, "  codebrev mcp [DIRECTORY]")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "Serve the Model Context Protocol over stdio for DIRECTORY (default: current directory).")
+		fmt.Fprintln(os.Stderr, "Tools: generate_outline, get_file_context, get_change_impact, list_contracts, get_package_graph.")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "Error: directory does not exist: %s\n", root)
+		return 1
+	}
+
+	build := func(dir string) (*outline.Outline, error) {
+		cfg, err := loadProjectConfig(dir, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid config: %v", err)
+		}
+		return loadOutline(dir, cliOptions{Config: cfg})
+	}
+	server := mcp.NewServer(root, build, Version)
+	if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+// Package check compares a committed markdown outline with a freshly
+// generated one, for `codebrev check` in CI.
+package check
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/writer"
+)
+
+// Section changes reported by Compare.
+const (
+	Changed = "changed"
+	Added   = "added"   // in the generated outline only
+	Removed = "removed" // in the committed outline only
+)
+
+// preamble names the text before the first "## " heading.
+const preamble = "(top of file)"
+
+// Difference is one "## " section that differs between the outlines.
+type Difference struct {
+	Section string
+	Change  string
+}
+
+// Result describes how a committed outline relates to the generated one.
+type Result struct {
+	// Current is true when the committed body is byte-identical to the
+	// generated body and matches the fingerprint in its own header.
+	Current bool
+	// HasHeader is false when the committed outline has no codebrev header,
+	// e.g. because it was written by an older version.
+	HasHeader bool
+	// Edited is true when the committed body no longer matches the
+	// fingerprint in its header, i.e. it was changed after generation.
+	Edited bool
+
+	CommittedVersion string
+	GeneratedVersion string
+
+	Differences []Difference
+}
+
+// Compare checks committed against generated, both complete markdown
+// outlines as written by the writer package.
+func Compare(committed, generated []byte) *Result {
+	committedHeader, committedBody, hasHeader := writer.SplitHeader(committed)
+	generatedHeader, generatedBody, _ := writer.SplitHeader(generated)
+
+	r := &Result{
+		HasHeader:        hasHeader,
+		CommittedVersion: committedHeader.Version,
+		GeneratedVersion: generatedHeader.Version,
+	}
+	if hasHeader {
+		r.Edited = writer.Fingerprint(committedBody) != committedHeader.Fingerprint
+	}
+	r.Current = hasHeader && !r.Edited && committedHeader.Fingerprint == writer.Fingerprint(generatedBody)
+	if r.Current {
+		return r
+	}
+
+	r.Differences = diffSections(splitSections(string(committedBody)), splitSections(string(generatedBody)))
+	return r
+}
+
+type section struct {
+	title string
+	text  string
+}
+
+// splitSections cuts a markdown body at each "## " heading. Repeated
+// titles are numbered so every section has a unique name.
+func splitSections(body string) []section {
+	var sections []section
+	seen := make(map[string]int)
+	current := section{title: preamble}
+	var text strings.Builder
+	flush := func() {
+		current.text = text.String()
+		sections = append(sections, current)
+		text.Reset()
+	}
+	for _, line := range strings.SplitAfter(body, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			flush()
+			title := strings.TrimSpace(line)
+			seen[title]++
+			if n := seen[title]; n > 1 {
+				title += " (" + strconv.Itoa(n) + ")"
+			}
+			current = section{title: title}
+		}
+		text.WriteString(line)
+	}
+	flush()
+	return sections
+}
+
+// diffSections lists sections that changed or were added, in generated
+// order, followed by sections that were removed, in committed order.
+func diffSections(committed, generated []section) []Difference {
+	old := make(map[string]string, len(committed))
+	for _, s := range committed {
+		old[s.title] = s.text
+	}
+	current := make(map[string]bool, len(generated))
+
+	var diffs []Difference
+	for _, s := range generated {
+		current[s.title] = true
+		text, ok := old[s.title]
+		switch {
+		case !ok:
+			diffs = append(diffs, Difference{Section: s.title, Change: Added})
+		case text != s.text:
+			diffs = append(diffs, Difference{Section: s.title, Change: Changed})
+		}
+	}
+	for _, s := range committed {
+		if !current[s.title] {
+			diffs = append(diffs, Difference{Section: s.title, Change: Removed})
+		}
+	}
+	return diffs
+}
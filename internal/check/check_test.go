@@ -0,0 +1,101 @@
+package check
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/writer"
+)
+
+// outline returns body with the header the writer would give it.
+func outline(version, body string) []byte {
+	return []byte(writer.Header{Version: version, Fingerprint: writer.Fingerprint([]byte(body))}.String() + body)
+}
+
+const body = "# Code Outline\n\n## Files\n\n- a.go\n\n## Public API\n\n- A()\n"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name      string
+		committed []byte
+		generated []byte
+		current   bool
+		hasHeader bool
+		edited    bool
+		diffs     []Difference
+	}{
+		{
+			name:      "identical",
+			committed: outline("v1", body),
+			generated: outline("v1", body),
+			current:   true,
+			hasHeader: true,
+		},
+		{
+			name:      "other version, same body",
+			committed: outline("v0.9", body),
+			generated: outline("v1", body),
+			current:   true,
+			hasHeader: true,
+		},
+		{
+			name:      "no header",
+			committed: []byte(body),
+			generated: outline("v1", body),
+		},
+		{
+			name:      "no header, stale",
+			committed: []byte("# Code Outline\n\n## Files\n\n- old.go\n"),
+			generated: outline("v1", body),
+			diffs:     []Difference{{"## Files", Changed}, {"## Public API", Added}},
+		},
+		{
+			name:      "hand edited",
+			committed: append(outline("v1", body), "- B()\n"...),
+			generated: outline("v1", body),
+			hasHeader: true,
+			edited:    true,
+			diffs:     []Difference{{"## Public API", Changed}},
+		},
+		{
+			name:      "stale",
+			committed: outline("v1", "# Code Outline\n\n## Files\n\n- a.go\n\n## Cycles\n\nnone\n"),
+			generated: outline("v1", body),
+			hasHeader: true,
+			diffs:     []Difference{{"## Public API", Added}, {"## Cycles", Removed}},
+		},
+		{
+			name:      "preamble",
+			committed: outline("v1", "# Old Title\n\n## Files\n\n- a.go\n\n## Public API\n\n- A()\n"),
+			generated: outline("v1", body),
+			hasHeader: true,
+			diffs:     []Difference{{preamble, Changed}},
+		},
+		{
+			name:      "repeated titles",
+			committed: outline("v1", "## Notes\n\na\n\n## Notes\n\nb\n"),
+			generated: outline("v1", "## Notes\n\na\n\n## Notes\n\nc\n\n## Notes\n\nd\n"),
+			hasHeader: true,
+			diffs:     []Difference{{"## Notes (2)", Changed}, {"## Notes (3)", Added}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Compare(tt.committed, tt.generated)
+			if r.Current != tt.current || r.HasHeader != tt.hasHeader || r.Edited != tt.edited {
+				t.Errorf("Current, HasHeader, Edited = %v, %v, %v, want %v, %v, %v",
+					r.Current, r.HasHeader, r.Edited, tt.current, tt.hasHeader, tt.edited)
+			}
+			if !slices.Equal(r.Differences, tt.diffs) {
+				t.Errorf("Differences = %v, want %v", r.Differences, tt.diffs)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	r := Compare(outline("v0.9", body), outline("v1", body))
+	if r.CommittedVersion != "v0.9" || r.GeneratedVersion != "v1" {
+		t.Errorf("versions = %q, %q, want %q, %q", r.CommittedVersion, r.GeneratedVersion, "v0.9", "v1")
+	}
+}
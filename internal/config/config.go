@@ -0,0 +1,246 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jasonwillschiu/codebrev/internal/layering"
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+	"github.com/jasonwillschiu/codebrev/internal/parser"
+	"github.com/jasonwillschiu/codebrev/internal/writer"
+)
+
+// FileName is the project config file looked up in the scan root.
+const FileName = ".codebrev.yaml"
+
+// Config is the contents of a .codebrev.yaml file. Every key is optional;
+// command-line flags take precedence over it.
+type Config struct {
+	// Output is the markdown outline path, relative to the config file's
+	// directory. JSON output is written next to it with a .json extension.
+	Output string `yaml:"output"`
+	// Include and Exclude are gitignore-style patterns relative to the scan
+	// root. When Include is set, only matching files are parsed.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// Languages limits parsing to these languages (see parser.Languages).
+	Languages []string `yaml:"languages"`
+	// Sections limits the markdown outline to these sections, in their
+	// usual order (see writer.SectionNames).
+	Sections []string `yaml:"sections"`
+	// Docs is how much of each Go doc comment the outline shows: "first"
+	// (default), "full" or "none".
+	Docs string `yaml:"docs"`
+	Risk Risk   `yaml:"risk"`
+	// Aliases maps TS/JS import prefixes to repo-relative paths, e.g.
+	// "@/": "src/". Replaces the default {"~": "src"} when set.
+	Aliases map[string]string `yaml:"aliases"`
+	// Analysis selects how Go code is analyzed: "syntax" (default) or
+	// "types" (see parser.Options.Analysis).
+	Analysis string `yaml:"analysis"`
+	// Architecture declares which packages may depend on which.
+	Architecture Architecture `yaml:"architecture"`
+
+	// Path is the file the config was loaded from; set by Load.
+	Path string `yaml:"-"`
+}
+
+// Risk holds the dependent counts above which a change is rated medium or
+// high risk. Zero keeps the defaults.
+type Risk struct {
+	Medium int `yaml:"medium"`
+	High   int `yaml:"high"`
+}
+
+// Architecture holds the layering rules checked by `codebrev lint-arch`
+// and reported in the outline.
+type Architecture struct {
+	Rules []layering.Rule `yaml:"rules"`
+}
+
+// DefaultPath returns where Find looks for the config file of a scan root,
+// whether or not the file exists. When root is a file, its directory is used.
+func DefaultPath(root string) string {
+	dir := root
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		dir = filepath.Dir(root)
+	}
+	return filepath.Join(dir, FileName)
+}
+
+// Find returns the config file for a scan root, or "" if there is none.
+func Find(root string) string {
+	path := DefaultPath(root)
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return path
+	}
+	return ""
+}
+
+// Discover loads the config file Find returns for root, or returns nil
+// when there is none.
+func Discover(root string) (*Config, error) {
+	path := Find(root)
+	if path == "" {
+		return nil, nil
+	}
+	return Load(path)
+}
+
+// Load reads and validates the config file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.Path = path
+	return cfg, nil
+}
+
+// Decode parses and validates a config document. Unknown keys are errors.
+func Decode(r io.Reader) (*Config, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, friendlyError(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks values that YAML decoding cannot.
+func (c *Config) Validate() error {
+	for _, lang := range c.Languages {
+		if _, ok := parser.Languages[lang]; !ok {
+			return fmt.Errorf("languages: unknown language %q (expected one of: %s)", lang, strings.Join(sortedLanguages(), ", "))
+		}
+	}
+	for _, section := range c.Sections {
+		if !writer.IsSection(section) {
+			return fmt.Errorf("sections: unknown section %q (expected one of: %s)", section, strings.Join(writer.SectionNames, ", "))
+		}
+	}
+	if !writer.IsDocsMode(c.Docs) {
+		return fmt.Errorf("docs: unknown mode %q (expected %q, %q or %q)", c.Docs, writer.DocsFirst, writer.DocsFull, writer.DocsNone)
+	}
+	if c.Risk.Medium < 0 || c.Risk.High < 0 {
+		return fmt.Errorf("risk: thresholds must not be negative")
+	}
+	medium, high := c.Risk.Medium, c.Risk.High
+	if medium == 0 {
+		medium = outline.DefaultRiskMedium
+	}
+	if high == 0 {
+		high = outline.DefaultRiskHigh
+	}
+	if medium >= high {
+		return fmt.Errorf("risk: medium (%d) must be lower than high (%d)", medium, high)
+	}
+	for prefix := range c.Aliases {
+		if prefix == "" {
+			return fmt.Errorf("aliases: empty prefix")
+		}
+	}
+	if !parser.IsAnalysis(c.Analysis) {
+		return fmt.Errorf("analysis: unknown mode %q (expected %q or %q)", c.Analysis, parser.AnalysisSyntax, parser.AnalysisTypes)
+	}
+	for i, rule := range c.Architecture.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("architecture.rules[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// OutputPath returns Output resolved against the config file's directory,
+// or "" when unset.
+func (c *Config) OutputPath() string {
+	if c == nil || c.Output == "" {
+		return ""
+	}
+	if filepath.IsAbs(c.Output) {
+		return c.Output
+	}
+	return filepath.Join(filepath.Dir(c.Path), c.Output)
+}
+
+// JSONOutputPath returns the path JSON output goes to: OutputPath with a
+// .json extension, e.g. "docs/codebrev.json" for "docs/codebrev.md", or ""
+// when Output is unset.
+func (c *Config) JSONOutputPath() string {
+	path := c.OutputPath()
+	if path == "" {
+		return ""
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".json"
+}
+
+// ParserOptions returns the parser options the config sets; the caller
+// adds run settings such as the cache directory. A nil config sets none.
+func (c *Config) ParserOptions() parser.Options {
+	if c == nil {
+		return parser.Options{}
+	}
+	return parser.Options{
+		Include:   c.Include,
+		Exclude:   c.Exclude,
+		Languages: c.Languages,
+		Aliases:   c.Aliases,
+		Analysis:  c.Analysis,
+	}
+}
+
+// WriterOptions returns the writer options the config sets; the caller
+// adds run settings such as the version. A nil config sets none.
+func (c *Config) WriterOptions() writer.Options {
+	if c == nil {
+		return writer.Options{}
+	}
+	return writer.Options{
+		Sections:  c.Sections,
+		ArchRules: c.Architecture.Rules,
+		Docs:      c.Docs,
+	}
+}
+
+var unknownFieldRE = regexp.MustCompile(`field (\S+) not found in type \S+`)
+
+// friendlyError rewrites yaml.v3's per-field errors ("line 3: field outptu
+// not found in type config.Config") in terms of config keys.
+func friendlyError(err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+	msgs := make([]string, 0, len(typeErr.Errors))
+	for _, msg := range typeErr.Errors {
+		msgs = append(msgs, unknownFieldRE.ReplaceAllString(msg, `unknown key "$1"`))
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+func sortedLanguages() []string {
+	names := make([]string, 0, len(parser.Languages))
+	for name := range parser.Languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string // "" for a valid document
+	}{
+		{name: "empty document", doc: ""},
+		{name: "comments only", doc: "# nothing yet\n"},
+		{
+			name: "full",
+			doc: `output: docs/codebrev.md
+include: ["src/**"]
+exclude: ["**/gen/**"]
+languages: [go, typescript]
+sections: [public-api, files]
+docs: full
+risk: {medium: 2, high: 5}
+aliases: {"@/": "src/"}
+analysis: types
+architecture:
+  rules:
+    - from: internal/...
+      deny: [cmd/...]
+`,
+		},
+		{name: "unknown key", doc: "outptu: x.md\n", wantErr: `line 1: unknown key "outptu"`},
+		{name: "unknown nested key", doc: "risk: {low: 1}\n", wantErr: `unknown key "low"`},
+		{name: "wrong type", doc: "include: src\n", wantErr: "cannot unmarshal"},
+		{name: "bad language", doc: "languages: [rust]\n", wantErr: `languages: unknown language "rust"`},
+		{name: "bad section", doc: "sections: [summary]\n", wantErr: `sections: unknown section "summary"`},
+		{name: "bad docs mode", doc: "docs: all\n", wantErr: `docs: unknown mode "all"`},
+		{name: "bad analysis mode", doc: "analysis: ssa\n", wantErr: `analysis: unknown mode "ssa"`},
+		{name: "negative risk", doc: "risk: {medium: -1}\n", wantErr: "risk: thresholds must not be negative"},
+		{name: "medium equals high", doc: "risk: {medium: 4, high: 4}\n", wantErr: "risk: medium (4) must be lower than high (4)"},
+		{name: "medium above default high", doc: "risk: {medium: 50}\n", wantErr: "must be lower than high"},
+		{name: "high below default medium", doc: "risk: {high: 1}\n", wantErr: "must be lower than high (1)"},
+		{name: "empty alias prefix", doc: "aliases: {\"\": src}\n", wantErr: "aliases: empty prefix"},
+		{
+			name:    "rule without allow or deny",
+			doc:     "architecture:\n  rules:\n    - from: cmd\n",
+			wantErr: `architecture.rules[0]: rule for "cmd" needs allow or deny`,
+		},
+		{
+			name:    "rule with a bad pattern",
+			doc:     "architecture:\n  rules:\n    - from: cmd\n      deny: [ok]\n    - from: /abs\n      deny: [x]\n",
+			wantErr: `architecture.rules[1]: invalid package pattern "/abs"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Decode(strings.NewReader(tt.doc))
+			if tt.wantErr == "" {
+				if err != nil || cfg == nil {
+					t.Fatalf("Decode = %v, %v, want a config", cfg, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Decode error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOutputPaths(t *testing.T) {
+	abs := filepath.Join(string(filepath.Separator), "abs", "out.md")
+	tests := []struct {
+		name     string
+		cfg      *Config
+		wantMD   string
+		wantJSON string
+	}{
+		{name: "nil config", cfg: nil},
+		{name: "unset", cfg: &Config{Path: "proj/.codebrev.yaml"}},
+		{
+			name:     "relative to the config file",
+			cfg:      &Config{Output: "docs/codebrev.md", Path: filepath.Join("proj", FileName)},
+			wantMD:   filepath.Join("proj", "docs", "codebrev.md"),
+			wantJSON: filepath.Join("proj", "docs", "codebrev.json"),
+		},
+		{
+			name:     "no extension",
+			cfg:      &Config{Output: "OUTLINE", Path: FileName},
+			wantMD:   "OUTLINE",
+			wantJSON: "OUTLINE.json",
+		},
+		{
+			name:     "absolute",
+			cfg:      &Config{Output: abs, Path: filepath.Join("proj", FileName)},
+			wantMD:   abs,
+			wantJSON: filepath.Join(filepath.Dir(abs), "out.json"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.OutputPath(); got != tt.wantMD {
+				t.Errorf("OutputPath = %q, want %q", got, tt.wantMD)
+			}
+			if got := tt.cfg.JSONOutputPath(); got != tt.wantJSON {
+				t.Errorf("JSONOutputPath = %q, want %q", got, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	if cfg, err := Discover(dir); cfg != nil || err != nil {
+		t.Fatalf("Discover without a config = %v, %v, want nil, nil", cfg, err)
+	}
+
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte("output: docs/out.md\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Discover(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Path != path || cfg.OutputPath() != filepath.Join(dir, "docs", "out.md") {
+		t.Errorf("Discover = Path %q, OutputPath %q", cfg.Path, cfg.OutputPath())
+	}
+
+	if err := os.WriteFile(path, []byte("docs: all\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Discover(dir); err == nil || !strings.HasPrefix(err.Error(), path+": ") {
+		t.Errorf("Discover error = %v, want it prefixed with the config path", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 // Pattern represents a gitignore pattern with its base directory
@@ -15,12 +16,15 @@ type Pattern struct {
 	BaseDir string // directory where this pattern was defined
 }
 
-// Gitignore handles gitignore pattern matching
+// Gitignore handles gitignore pattern matching. ShouldIgnore is safe for
+// concurrent use.
 type Gitignore struct {
 	Patterns   []Pattern
 	Root       string
 	GitRoot    string
 	LoadedDirs map[string]bool // Track which directories we've loaded .gitignore from
+
+	mu sync.Mutex // guards Patterns and LoadedDirs once New returns
 }
 
 type normalizedPattern struct {
@@ -71,6 +75,9 @@ func New(root string) *Gitignore {
 
 // ShouldIgnore checks if a path should be ignored based on gitignore patterns
 func (gi *Gitignore) ShouldIgnore(path string) bool {
+	gi.mu.Lock()
+	defer gi.mu.Unlock()
+
 	// Dynamically load .gitignore files from directories we encounter
 	gi.loadGitignoreFromPath(path)
 
@@ -91,7 +98,7 @@ func (gi *Gitignore) ShouldIgnore(path string) bool {
 			continue
 		}
 
-		if match, negated := gi.matchPattern(relPath, patternInfo.Pattern); match {
+		if match, negated := matchPattern(relPath, patternInfo.Pattern); match {
 			ignored = !negated
 		}
 	}
@@ -228,8 +235,10 @@ func normalizeGitignorePattern(raw string) (normalizedPattern, bool) {
 		negated = true
 		p = strings.TrimPrefix(p, "!")
 	}
-	p = strings.TrimPrefix(p, `\!`)
-	p = strings.TrimPrefix(p, `\#`)
+	// "\!" and "\#" escape a literal leading "!" or "#".
+	if strings.HasPrefix(p, `\!`) || strings.HasPrefix(p, `\#`) {
+		p = p[1:]
+	}
 
 	anchored := strings.HasPrefix(p, "/")
 	p = strings.TrimPrefix(p, "/")
@@ -246,7 +255,8 @@ func normalizeGitignorePattern(raw string) (normalizedPattern, bool) {
 		p = strings.TrimSuffix(p, "/")
 	}
 
-	noSlash := !strings.Contains(p, "/")
+	// A leading slash anchors even a single name to the base directory.
+	noSlash := !anchored && !strings.Contains(p, "/")
 
 	return normalizedPattern{
 		pattern:  p,
@@ -268,6 +278,12 @@ func globToRegexFragment(glob string) string {
 		switch c {
 		case '*':
 			if i+1 < len(glob) && glob[i+1] == '*' {
+				// "**/" also matches zero directories ("**/gen" matches "gen").
+				if i+2 < len(glob) && glob[i+2] == '/' && (i == 0 || glob[i-1] == '/') {
+					b.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				}
 				b.WriteString(".*")
 				i++
 				continue
@@ -329,9 +345,41 @@ func compileDirGlobToRegex(glob string) *regexp.Regexp {
 	return re
 }
 
+// Matcher evaluates a list of gitignore-style patterns against paths
+// relative to a single base directory, e.g. for include/exclude filters.
+type Matcher struct {
+	patterns []string
+}
+
+// NewMatcher returns a matcher for patterns; blank lines and comments are
+// skipped as in a .gitignore file.
+func NewMatcher(patterns []string) *Matcher {
+	return &Matcher{patterns: patterns}
+}
+
+// Empty reports whether the matcher has no patterns.
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.patterns) == 0
+}
+
+// Match reports whether the slash-separated relPath matches. As in
+// .gitignore, the last matching pattern wins and a "!" pattern un-matches.
+func (m *Matcher) Match(relPath string) bool {
+	if m == nil {
+		return false
+	}
+	matched := false
+	for _, pattern := range m.patterns {
+		if match, negated := matchPattern(relPath, pattern); match {
+			matched = !negated
+		}
+	}
+	return matched
+}
+
 // matchPattern checks if a path matches a gitignore pattern.
 // It returns (matched, negated).
-func (gi *Gitignore) matchPattern(relPath, rawPattern string) (bool, bool) {
+func matchPattern(relPath, rawPattern string) (bool, bool) {
 	np, ok := normalizeGitignorePattern(rawPattern)
 	if !ok {
 		return false, false
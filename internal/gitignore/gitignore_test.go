@@ -0,0 +1,57 @@
+package gitignore
+
+import "testing"
+
+func TestMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{"no patterns", nil, "a.go", false},
+		{"blank and comment", []string{"", "# a.go"}, "a.go", false},
+		{"basename anywhere", []string{"*.pb.go"}, "api/v1/user.pb.go", true},
+		{"basename no match", []string{"*.pb.go"}, "api/v1/user.go", false},
+		{"dir pattern matches contents", []string{"vendor/"}, "vendor/x/y.go", true},
+		{"dir pattern at any depth", []string{"generated/"}, "pkg/generated/z.go", true},
+		{"dir pattern is not a prefix", []string{"gen/"}, "generator/a.go", false},
+		{"path pattern", []string{"internal/*.go"}, "internal/a.go", true},
+		{"single star stays in segment", []string{"internal/*.go"}, "internal/sub/a.go", false},
+		{"double star", []string{"services/billing/**"}, "services/billing/api/h.go", true},
+		{"double star prefix", []string{"**/testdata/**"}, "a/b/testdata/x.go", true},
+		{"leading double star at root", []string{"**/mock_*.go"}, "mock_db.go", true},
+		{"anchored", []string{"/main.go"}, "main.go", true},
+		{"anchored not nested", []string{"/main.go"}, "cmd/main.go", false},
+		{"anchored dir", []string{"/build/"}, "build/out.go", true},
+		{"anchored dir not nested", []string{"/build/"}, "web/build/out.go", false},
+		{"middle slash is relative to base", []string{"internal/*.go"}, "x/internal/a.go", false},
+		{"escaped hash", []string{`\#x.go`}, "#x.go", true},
+		{"question mark", []string{"a?.go"}, "ab.go", true},
+		{"character class", []string{"[ab].go"}, "c.go", false},
+		{"negation re-includes", []string{"*.go", "!keep.go"}, "keep.go", false},
+		{"negation leaves others", []string{"*.go", "!keep.go"}, "drop.go", true},
+		{"last match wins", []string{"!keep.go", "*.go"}, "keep.go", true},
+		{"escaped bang", []string{`\!x.go`}, "!x.go", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewMatcher(tt.patterns).Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) with %q = %v, want %v", tt.path, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherEmpty(t *testing.T) {
+	var nilMatcher *Matcher
+	if !nilMatcher.Empty() || nilMatcher.Match("a.go") {
+		t.Error("nil matcher should be empty and match nothing")
+	}
+	if !NewMatcher(nil).Empty() {
+		t.Error("NewMatcher(nil) should be empty")
+	}
+	if NewMatcher([]string{"*.go"}).Empty() {
+		t.Error("NewMatcher with a pattern should not be empty")
+	}
+}
@@ -0,0 +1,191 @@
+package impact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// ChangedFile is a changed path that maps onto a file in the outline.
+type ChangedFile struct {
+	Path      string `json:"path"`
+	RiskLevel string `json:"riskLevel"`
+}
+
+// ContractChange is a tagged struct declared in a changed file.
+type ContractChange struct {
+	Type string   `json:"type"`
+	File string   `json:"file"`
+	Keys []string `json:"keys"`
+}
+
+// RouteChange is a route registered in a changed file.
+type RouteChange struct {
+	File  string `json:"file"`
+	Route string `json:"route"`
+}
+
+// ChangeReport is the blast radius of a set of changed paths, typically the
+// files touched by a branch or pull request.
+type ChangeReport struct {
+	Since             string           `json:"since,omitempty"`
+	RiskLevel         string           `json:"riskLevel"` // aggregate across all changes
+	ChangedFiles      []ChangedFile    `json:"changedFiles"`
+	OtherChanges      []string         `json:"otherChanges"` // changed paths the outline doesn't cover
+	ChangedPackages   []string         `json:"changedPackages"`
+	DependentFiles    []Dependent      `json:"dependentFiles"`
+	DependentPackages []Dependent      `json:"dependentPackages"`
+	Contracts         []ContractChange `json:"contracts"`
+	Routes            []RouteChange    `json:"routes"`
+}
+
+// CheckRef rejects git refs that git would parse as an option, such as
+// "--output=file", before they reach the command line.
+func CheckRef(ref string) error {
+	if ref == "" {
+		return fmt.Errorf("empty git ref")
+	}
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid git ref %q: must not start with '-'", ref)
+	}
+	return nil
+}
+
+// GitChangedFiles returns the paths changed between ref and HEAD
+// (`git diff --name-only ref...HEAD`), relative to root. Paths outside root
+// are left out. The ref is resolved to a commit first, so only commits
+// reach git diff.
+func GitChangedFiles(root, ref string) ([]string, error) {
+	if err := CheckRef(ref); err != nil {
+		return nil, err
+	}
+	commit, err := runGit(root, "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("git ref %q: not a commit", ref)
+	}
+	commit = strings.TrimSpace(commit)
+
+	output, err := runGit(root, "diff", "--name-only", "--relative", "--end-of-options", commit+"...HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s...HEAD: %v", ref, err)
+	}
+
+	var paths []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
+
+// runGit runs git in dir and returns its standard output. Errors carry
+// git's message when it printed one.
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+// AnalyzeChanges maps repo-relative changed paths onto the outline and
+// collects their combined dependents, touched contracts and aggregate risk.
+func AnalyzeChanges(out *outline.Outline, changed []string) *ChangeReport {
+	report := &ChangeReport{}
+
+	risk := "low"
+	pkgSet := make(map[string]bool)
+	var changedFiles []string
+	for _, path := range changed {
+		fi, ok := out.Files[path]
+		if !ok {
+			report.OtherChanges = append(report.OtherChanges, path)
+			continue
+		}
+		changedFiles = append(changedFiles, path)
+
+		fileRisk := out.CalculateChangeImpact(path).RiskLevel
+		report.ChangedFiles = append(report.ChangedFiles, ChangedFile{Path: path, RiskLevel: fileRisk})
+		risk = maxRisk(risk, fileRisk)
+
+		pkg := fi.PackageDir
+		if pkg == "" {
+			pkg = "."
+		}
+		pkgSet[pkg] = true
+
+		report.Contracts = append(report.Contracts, contractsInFile(out, fi)...)
+		for _, route := range fi.Routes {
+			report.Routes = append(report.Routes, RouteChange{File: path, Route: route})
+		}
+	}
+	sort.Strings(changedFiles)
+	sort.Strings(report.OtherChanges)
+
+	for pkg := range pkgSet {
+		report.ChangedPackages = append(report.ChangedPackages, pkg)
+	}
+	sort.Strings(report.ChangedPackages)
+	for _, pkg := range report.ChangedPackages {
+		if _, ok := out.PackageReverseDeps[pkg]; ok {
+			risk = maxRisk(risk, out.CalculatePackageChangeImpact(pkg).RiskLevel)
+		}
+	}
+
+	report.DependentFiles = walkDependents(out.ReverseDeps, changedFiles)
+	report.DependentPackages = walkDependents(out.PackageReverseDeps, report.ChangedPackages)
+	report.RiskLevel = maxRisk(risk, out.RiskLevelFor(len(report.DependentFiles)))
+
+	sort.Slice(report.ChangedFiles, func(i, j int) bool {
+		return report.ChangedFiles[i].Path < report.ChangedFiles[j].Path
+	})
+	sort.Slice(report.Contracts, func(i, j int) bool {
+		if report.Contracts[i].File != report.Contracts[j].File {
+			return report.Contracts[i].File < report.Contracts[j].File
+		}
+		return report.Contracts[i].Type < report.Contracts[j].Type
+	})
+	sort.Slice(report.Routes, func(i, j int) bool {
+		if report.Routes[i].File != report.Routes[j].File {
+			return report.Routes[i].File < report.Routes[j].File
+		}
+		return report.Routes[i].Route < report.Routes[j].Route
+	})
+	return report
+}
+
+func contractsInFile(out *outline.Outline, fi *outline.FileInfo) []ContractChange {
+	var contracts []ContractChange
+	for _, name := range fi.Types {
+		ti := out.FileType(fi, name)
+		if ti == nil || len(ti.ContractKeys) == 0 {
+			continue
+		}
+		keys := append([]string(nil), ti.ContractKeys...)
+		sort.Strings(keys)
+		contracts = append(contracts, ContractChange{Type: name, File: fi.Path, Keys: keys})
+	}
+	return contracts
+}
+
+var riskRank = map[string]int{"low": 0, "medium": 1, "high": 2}
+
+func maxRisk(a, b string) string {
+	if riskRank[b] > riskRank[a] {
+		return b
+	}
+	return a
+}
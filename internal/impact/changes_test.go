@@ -0,0 +1,89 @@
+package impact
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a repository with two commits: the first adds a.go, the
+// second adds b/b.go and changes a.go.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("a.go", "package a\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "first")
+	git("tag", "base")
+	write("a.go", "package a\n\nfunc A() {}\n")
+	write("b/b.go", "package b\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "second")
+	return dir
+}
+
+func TestGitChangedFiles(t *testing.T) {
+	dir := gitRepo(t)
+	outputFile := filepath.Join(t.TempDir(), "clobbered")
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    []string
+		wantErr string
+	}{
+		{name: "tag", ref: "base", want: []string{"a.go", "b/b.go"}},
+		{name: "relative ref", ref: "HEAD~1", want: []string{"a.go", "b/b.go"}},
+		{name: "HEAD", ref: "HEAD"},
+		{name: "empty", ref: "", wantErr: "empty git ref"},
+		{name: "option", ref: "--output=" + outputFile, wantErr: "must not start with '-'"},
+		{name: "short option", ref: "-p", wantErr: "must not start with '-'"},
+		{name: "unknown ref", ref: "no-such-branch", wantErr: "not a commit"},
+		{name: "range", ref: "base..HEAD", wantErr: "not a commit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GitChangedFiles(dir, tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GitChangedFiles(%q) error = %v, want %q", tt.ref, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GitChangedFiles(%q) = %v, want %v", tt.ref, got, tt.want)
+			}
+		})
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Errorf("a dash-prefixed ref reached git: %s exists", outputFile)
+	}
+}
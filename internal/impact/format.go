@@ -0,0 +1,98 @@
+package impact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteJSON writes a Report or ChangeReport as indented JSON.
+func WriteJSON(w io.Writer, report any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(report)
+}
+
+// WriteText writes a human-readable report. Each dependent is followed by
+// the chain that reaches it, read as "changed -> used by -> used by".
+func WriteText(w io.Writer, r *Report) error {
+	var sb strings.Builder
+	for i, tr := range r.Targets {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		writeTargetText(&sb, tr)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeTargetText(sb *strings.Builder, tr TargetReport) {
+	fmt.Fprintf(sb, "%s (%s, risk: %s)\n", tr.Target, tr.Kind, tr.RiskLevel)
+	writeDependentsText(sb, "Dependent files", tr.DependentFiles)
+	writeDependentsText(sb, "Dependent packages", tr.DependentPackages)
+}
+
+func writeDependentsText(sb *strings.Builder, title string, deps []Dependent) {
+	if len(deps) == 0 {
+		fmt.Fprintf(sb, "  %s: none\n", title)
+		return
+	}
+	fmt.Fprintf(sb, "  %s (%d):\n", title, len(deps))
+	for _, d := range deps {
+		label := "direct"
+		if d.Depth > 1 {
+			label = fmt.Sprintf("depth %d", d.Depth)
+		}
+		fmt.Fprintf(sb, "    - %s [%s] via %s\n", d.Path, label, strings.Join(d.Via, " -> "))
+	}
+}
+
+// WriteChangesText writes a human-readable change report.
+func WriteChangesText(w io.Writer, r *ChangeReport) error {
+	var sb strings.Builder
+	if r.Since != "" {
+		fmt.Fprintf(&sb, "Changes since %s (aggregate risk: %s)\n", r.Since, r.RiskLevel)
+	} else {
+		fmt.Fprintf(&sb, "Changes (aggregate risk: %s)\n", r.RiskLevel)
+	}
+
+	if len(r.ChangedFiles) == 0 {
+		sb.WriteString("  Changed files: none\n")
+	} else {
+		fmt.Fprintf(&sb, "  Changed files (%d):\n", len(r.ChangedFiles))
+		for _, cf := range r.ChangedFiles {
+			fmt.Fprintf(&sb, "    - %s [risk: %s]\n", cf.Path, cf.RiskLevel)
+		}
+	}
+	if len(r.OtherChanges) > 0 {
+		fmt.Fprintf(&sb, "  Other changed paths (not in outline) (%d):\n", len(r.OtherChanges))
+		for _, path := range r.OtherChanges {
+			fmt.Fprintf(&sb, "    - %s\n", path)
+		}
+	}
+	if len(r.ChangedPackages) > 0 {
+		fmt.Fprintf(&sb, "  Changed packages: %s\n", strings.Join(r.ChangedPackages, ", "))
+	}
+
+	writeDependentsText(&sb, "Dependent files", r.DependentFiles)
+	writeDependentsText(&sb, "Dependent packages", r.DependentPackages)
+
+	if len(r.Contracts) > 0 {
+		fmt.Fprintf(&sb, "  Contracts touched (%d):\n", len(r.Contracts))
+		for _, c := range r.Contracts {
+			fmt.Fprintf(&sb, "    - %s in %s (keys: %s)\n", c.Type, c.File, strings.Join(c.Keys, ", "))
+		}
+	}
+	if len(r.Routes) > 0 {
+		fmt.Fprintf(&sb, "  Routes touched (%d):\n", len(r.Routes))
+		for _, route := range r.Routes {
+			fmt.Fprintf(&sb, "    - %s in %s\n", route.Route, route.File)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
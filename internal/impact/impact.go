@@ -0,0 +1,165 @@
+package impact
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// Target kinds.
+const (
+	KindFile    = "file"
+	KindPackage = "package"
+)
+
+// Dependent is a file or package affected by a change, together with the
+// chain of reverse dependencies that connects it back to the target.
+type Dependent struct {
+	Path  string   `json:"path"`
+	Depth int      `json:"depth"` // 1 = direct dependent
+	Via   []string `json:"via"`   // changed -> ... -> Path
+}
+
+// TargetReport is the blast radius of a single file or package.
+type TargetReport struct {
+	Target            string      `json:"target"`
+	Kind              string      `json:"kind"`
+	RiskLevel         string      `json:"riskLevel"`
+	DependentFiles    []Dependent `json:"dependentFiles"`
+	DependentPackages []Dependent `json:"dependentPackages"`
+}
+
+// Report is the result of an impact query.
+type Report struct {
+	Targets []TargetReport `json:"targets"`
+}
+
+// Analyze computes the blast radius for each target. Targets may be
+// repo-relative, relative to the working directory, or absolute, and may
+// name either a file or a package directory.
+func Analyze(out *outline.Outline, targets []string) (*Report, error) {
+	report := &Report{}
+	for _, arg := range targets {
+		target, kind, err := ResolveTarget(out, arg)
+		if err != nil {
+			return nil, err
+		}
+		report.Targets = append(report.Targets, analyzeTarget(out, target, kind))
+	}
+	return report, nil
+}
+
+// ResolveTarget maps a user-supplied path onto a key of out.Files or
+// out.Packages.
+func ResolveTarget(out *outline.Outline, arg string) (string, string, error) {
+	for _, candidate := range targetCandidates(out, arg) {
+		if _, ok := out.Files[candidate]; ok {
+			return candidate, KindFile, nil
+		}
+		if _, ok := out.Packages[candidate]; ok {
+			return candidate, KindPackage, nil
+		}
+	}
+	return "", "", fmt.Errorf("not a known file or package: %s", arg)
+}
+
+func targetCandidates(out *outline.Outline, arg string) []string {
+	cleaned := filepath.ToSlash(filepath.Clean(arg))
+	candidates := []string{cleaned}
+
+	if out.RootDir != "" {
+		if abs, err := filepath.Abs(arg); err == nil {
+			if rel, err := filepath.Rel(out.RootDir, abs); err == nil {
+				rel = filepath.ToSlash(rel)
+				if rel != ".." && !strings.HasPrefix(rel, "../") && rel != cleaned {
+					candidates = append(candidates, rel)
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+func analyzeTarget(out *outline.Outline, target, kind string) TargetReport {
+	tr := TargetReport{Target: target, Kind: kind}
+
+	switch kind {
+	case KindFile:
+		tr.RiskLevel = out.CalculateChangeImpact(target).RiskLevel
+		tr.DependentFiles = walkDependents(out.ReverseDeps, []string{target})
+		if fi := out.Files[target]; fi != nil {
+			pkg := fi.PackageDir
+			if pkg == "" {
+				pkg = "."
+			}
+			tr.DependentPackages = walkDependents(out.PackageReverseDeps, []string{pkg})
+		}
+	case KindPackage:
+		tr.RiskLevel = out.CalculatePackageChangeImpact(target).RiskLevel
+		tr.DependentPackages = walkDependents(out.PackageReverseDeps, []string{target})
+		if pkg := out.Packages[target]; pkg != nil {
+			sources := append([]string(nil), pkg.Files...)
+			sort.Strings(sources)
+			tr.DependentFiles = walkDependents(out.ReverseDeps, sources)
+		}
+	}
+	return tr
+}
+
+// walkDependents runs a breadth-first search over a reverse dependency graph
+// starting from sources. Each reachable node is reported once, at its
+// shortest distance, with the path that reached it. Neighbors are visited in
+// sorted order so the chosen path is deterministic.
+func walkDependents(reverse map[string][]string, sources []string) []Dependent {
+	parent := make(map[string]string)
+	depth := make(map[string]int)
+	for _, src := range sources {
+		depth[src] = 0
+	}
+
+	var result []Dependent
+	queue := append([]string(nil), sources...)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		next := append([]string(nil), reverse[node]...)
+		sort.Strings(next)
+		for _, dep := range next {
+			if _, seen := depth[dep]; seen {
+				continue
+			}
+			depth[dep] = depth[node] + 1
+			parent[dep] = node
+			queue = append(queue, dep)
+			result = append(result, Dependent{
+				Path:  dep,
+				Depth: depth[dep],
+				Via:   pathTo(dep, parent, depth),
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Depth != result[j].Depth {
+			return result[i].Depth < result[j].Depth
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+func pathTo(node string, parent map[string]string, depth map[string]int) []string {
+	chain := []string{node}
+	for depth[node] > 0 {
+		node = parent[node]
+		chain = append(chain, node)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
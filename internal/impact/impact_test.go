@@ -0,0 +1,223 @@
+package impact
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// testOutline builds a small Go repository rooted at /repo:
+//
+//	main.go -> c/c.go -> b/b.go -> a/a.go
+//	main.go -> a/a.go, d/d.go -> c/c.go, a/a2.go -> c/c.go (a cycle)
+func testOutline() *outline.Outline {
+	out := outline.New()
+	out.RootDir = "/repo"
+	for path, pkg := range map[string]string{
+		"a/a.go":  "a",
+		"a/a2.go": "a",
+		"b/b.go":  "b",
+		"c/c.go":  "c",
+		"d/d.go":  "d",
+		"main.go": ".",
+	} {
+		fi := out.AddFile(path, "/repo/"+path)
+		fi.PackageName = "p"
+		fi.PackageDir = pkg
+		info := out.Packages[pkg]
+		if info == nil {
+			info = &outline.PackageInfo{PackagePath: "example.com/m/" + pkg}
+			out.Packages[pkg] = info
+		}
+		info.Files = append(info.Files, path)
+	}
+	for _, dep := range [][2]string{
+		{"b/b.go", "a/a.go"}, {"c/c.go", "b/b.go"}, {"main.go", "c/c.go"},
+		{"main.go", "a/a.go"}, {"d/d.go", "c/c.go"}, {"a/a2.go", "c/c.go"},
+	} {
+		out.AddDependency(dep[0], dep[1])
+	}
+	for _, dep := range [][2]string{
+		{"b", "a"}, {"c", "b"}, {".", "c"}, {".", "a"}, {"d", "c"}, {"a", "c"},
+	} {
+		out.AddPackageDependency(dep[0], dep[1])
+	}
+	return out
+}
+
+// dependentStrings renders dependents as "path depth: via", e.g.
+// "c/c.go 2: a/a.go > b/b.go > c/c.go".
+func dependentStrings(deps []Dependent) []string {
+	var result []string
+	for _, d := range deps {
+		result = append(result, d.Path+" "+strconv.Itoa(d.Depth)+": "+strings.Join(d.Via, " > "))
+	}
+	return result
+}
+
+func TestResolveTarget(t *testing.T) {
+	out := testOutline()
+	tests := []struct {
+		arg      string
+		wantPath string
+		wantKind string
+		wantErr  bool
+	}{
+		{arg: "a/a.go", wantPath: "a/a.go", wantKind: KindFile},
+		{arg: "./a/a.go", wantPath: "a/a.go", wantKind: KindFile},
+		{arg: "b/../a/a.go", wantPath: "a/a.go", wantKind: KindFile},
+		{arg: "/repo/c/c.go", wantPath: "c/c.go", wantKind: KindFile},
+		{arg: "a", wantPath: "a", wantKind: KindPackage},
+		{arg: "a/", wantPath: "a", wantKind: KindPackage},
+		{arg: "/repo/d", wantPath: "d", wantKind: KindPackage},
+		{arg: ".", wantPath: ".", wantKind: KindPackage},
+		{arg: "/repo", wantPath: ".", wantKind: KindPackage},
+		{arg: "missing.go", wantErr: true},
+		{arg: "a/missing.go", wantErr: true},
+		{arg: "/elsewhere/a/a.go", wantErr: true},
+		{arg: "../a/a.go", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			path, kind, err := ResolveTarget(out, tt.arg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ResolveTarget(%q) = %q, %q, want an error", tt.arg, path, kind)
+				}
+				return
+			}
+			if err != nil || path != tt.wantPath || kind != tt.wantKind {
+				t.Errorf("ResolveTarget(%q) = %q, %q, %v, want %q, %q", tt.arg, path, kind, err, tt.wantPath, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		target       string
+		kind         string
+		wantFiles    []string
+		wantPackages []string
+	}{
+		{
+			target: "a/a.go",
+			kind:   KindFile,
+			wantFiles: []string{
+				"b/b.go 1: a/a.go > b/b.go",
+				"main.go 1: a/a.go > main.go",
+				"c/c.go 2: a/a.go > b/b.go > c/c.go",
+				// a/a2.go is reached through the cycle back into package a.
+				"a/a2.go 3: a/a.go > b/b.go > c/c.go > a/a2.go",
+				"d/d.go 3: a/a.go > b/b.go > c/c.go > d/d.go",
+			},
+			wantPackages: []string{
+				". 1: a > .",
+				"b 1: a > b",
+				"c 2: a > b > c",
+				"d 3: a > b > c > d",
+			},
+		},
+		{
+			target: "a",
+			kind:   KindPackage,
+			// Both files of the package are sources; a/a2.go is not its own dependent.
+			wantFiles: []string{
+				"b/b.go 1: a/a.go > b/b.go",
+				"main.go 1: a/a.go > main.go",
+				"c/c.go 2: a/a.go > b/b.go > c/c.go",
+				"d/d.go 3: a/a.go > b/b.go > c/c.go > d/d.go",
+			},
+			wantPackages: []string{
+				". 1: a > .",
+				"b 1: a > b",
+				"c 2: a > b > c",
+				"d 3: a > b > c > d",
+			},
+		},
+		{
+			target:       "main.go",
+			kind:         KindFile,
+			wantFiles:    nil,
+			wantPackages: nil,
+		},
+		{
+			target:    "d",
+			kind:      KindPackage,
+			wantFiles: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			report, err := Analyze(testOutline(), []string{tt.target})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr := report.Targets[0]
+			if tr.Target != tt.target || tr.Kind != tt.kind {
+				t.Errorf("target = %q (%s), want %q (%s)", tr.Target, tr.Kind, tt.target, tt.kind)
+			}
+			if got := dependentStrings(tr.DependentFiles); !slices.Equal(got, tt.wantFiles) {
+				t.Errorf("DependentFiles =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.wantFiles, "\n"))
+			}
+			if got := dependentStrings(tr.DependentPackages); !slices.Equal(got, tt.wantPackages) {
+				t.Errorf("DependentPackages =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.wantPackages, "\n"))
+			}
+		})
+	}
+
+	if _, err := Analyze(testOutline(), []string{"a/a.go", "nope"}); err == nil {
+		t.Error("Analyze with an unknown target succeeded")
+	}
+}
+
+func TestWalkDependents(t *testing.T) {
+	tests := []struct {
+		name    string
+		reverse map[string][]string
+		sources []string
+		want    []string
+	}{
+		{"no dependents", map[string][]string{"x": nil}, []string{"x"}, nil},
+		{
+			"shortest path wins",
+			map[string][]string{"x": {"y", "z"}, "y": {"w"}, "z": {"y"}},
+			[]string{"x"},
+			[]string{"y 1: x > y", "z 1: x > z", "w 2: x > y > w"},
+		},
+		{
+			"ties broken by sorted neighbours",
+			map[string][]string{"x": {"q", "p"}, "p": {"w"}, "q": {"w"}},
+			[]string{"x"},
+			[]string{"p 1: x > p", "q 1: x > q", "w 2: x > p > w"},
+		},
+		{
+			"cycle terminates",
+			map[string][]string{"x": {"y"}, "y": {"z"}, "z": {"x", "y"}},
+			[]string{"x"},
+			[]string{"y 1: x > y", "z 2: x > y > z"},
+		},
+		{
+			"self dependency",
+			map[string][]string{"x": {"x", "y"}},
+			[]string{"x"},
+			[]string{"y 1: x > y"},
+		},
+		{
+			"several sources",
+			map[string][]string{"x": {"z"}, "y": {"z", "w"}, "w": {"x"}},
+			[]string{"x", "y"},
+			[]string{"w 1: y > w", "z 1: x > z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dependentStrings(walkDependents(tt.reverse, tt.sources)); !slices.Equal(got, tt.want) {
+				t.Errorf("walkDependents = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
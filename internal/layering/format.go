@@ -0,0 +1,40 @@
+package layering
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteJSON writes the violations as an indented JSON array. No violations
+// are written as [] rather than null.
+func WriteJSON(w io.Writer, violations []Violation) error {
+	if violations == nil {
+		violations = []Violation{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(violations)
+}
+
+// WriteText writes a human-readable violation report.
+func WriteText(w io.Writer, violations []Violation) error {
+	var sb strings.Builder
+	if len(violations) == 0 {
+		sb.WriteString("No architecture violations.\n")
+	} else {
+		fmt.Fprintf(&sb, "%d architecture violation(s):\n", len(violations))
+	}
+	for _, v := range violations {
+		fmt.Fprintf(&sb, "  %s -> %s\n", v.From, v.To)
+		fmt.Fprintf(&sb, "    rule: %s\n", v.Reason)
+		fmt.Fprintf(&sb, "    edge: %d imports, %d calls, %d type uses\n", v.Stats.Imports, v.Stats.Calls, v.Stats.TypeUses)
+		if len(v.Files) > 0 {
+			fmt.Fprintf(&sb, "    files: %s\n", strings.Join(v.Files, ", "))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,184 @@
+// Package layering evaluates architecture rules (which packages may depend
+// on which) against the package graph of an outline.
+package layering
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// Rule constrains the local package dependencies of every package matching
+// From. A dependency matching any Deny pattern is a violation; when Allow is
+// set, so is every dependency that matches none of its patterns.
+//
+// Patterns are repo-relative package directories. A trailing "/..." also
+// matches every package below the directory, and path.Match wildcards are
+// allowed in each segment: "internal/writer", "internal/...", "cmd/*".
+type Rule struct {
+	From  string   `yaml:"from" json:"from"`
+	Allow []string `yaml:"allow" json:"allow,omitempty"`
+	Deny  []string `yaml:"deny" json:"deny,omitempty"`
+}
+
+// Violation is a package dependency that breaks a rule.
+type Violation struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Rule   Rule   `json:"rule"`
+	Reason string `json:"reason"`
+	// Files are the files in From that depend on To.
+	Files []string `json:"files"`
+	// Stats counts the imports, calls and type uses behind the edge.
+	Stats outline.EdgeStat `json:"stats"`
+}
+
+// Validate checks that the rule is usable.
+func (r Rule) Validate() error {
+	if r.From == "" {
+		return fmt.Errorf("rule without from")
+	}
+	if len(r.Allow) == 0 && len(r.Deny) == 0 {
+		return fmt.Errorf("rule for %q needs allow or deny", r.From)
+	}
+	for _, pattern := range append(append([]string{r.From}, r.Allow...), r.Deny...) {
+		if err := validatePattern(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePattern(pattern string) error {
+	if pattern == "" || strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("invalid package pattern %q", pattern)
+	}
+	if _, err := path.Match(strings.TrimSuffix(pattern, "/..."), ""); err != nil {
+		return fmt.Errorf("invalid package pattern %q: %v", pattern, err)
+	}
+	return nil
+}
+
+// Match reports whether the package directory pkg matches pattern.
+func Match(pattern, pkg string) bool {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+	if pattern == "..." {
+		return true
+	}
+	if base, ok := strings.CutSuffix(pattern, "/..."); ok {
+		if base == "." || base == "" {
+			return true
+		}
+		for p := pkg; ; p = path.Dir(p) {
+			if matchDir(base, p) {
+				return true
+			}
+			if p == "." || p == "/" {
+				return false
+			}
+		}
+	}
+	return matchDir(pattern, pkg)
+}
+
+func matchDir(pattern, pkg string) bool {
+	ok, err := path.Match(pattern, pkg)
+	return err == nil && ok
+}
+
+func matchAny(patterns []string, pkg string) (string, bool) {
+	for _, pattern := range patterns {
+		if Match(pattern, pkg) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// Check evaluates rules against the outline's package dependencies and edge
+// stats. Violations are sorted by From, then To; a dependency broken by
+// several rules is reported once, for the first of them.
+func Check(out *outline.Outline, rules []Rule) []Violation {
+	edges := make(map[string]map[string]bool)
+	addEdge := func(from, to string) {
+		if from == to {
+			return
+		}
+		if edges[from] == nil {
+			edges[from] = make(map[string]bool)
+		}
+		edges[from][to] = true
+	}
+	for from, deps := range out.PackageDeps {
+		for _, to := range deps {
+			addEdge(from, to)
+		}
+	}
+	for from, stats := range out.PackageEdgeStats {
+		for to := range stats {
+			addEdge(from, to)
+		}
+	}
+
+	var violations []Violation
+	for _, from := range sortedKeys(edges) {
+		for _, to := range sortedKeys(edges[from]) {
+			for _, rule := range rules {
+				reason, broken := rule.breaks(from, to)
+				if !broken {
+					continue
+				}
+				violations = append(violations, Violation{
+					From:   from,
+					To:     to,
+					Rule:   rule,
+					Reason: reason,
+					Files:  filesDependingOn(out, from, to),
+					Stats:  out.PackageEdgeStats[from][to],
+				})
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// breaks reports whether the dependency from -> to violates the rule, and why.
+func (r Rule) breaks(from, to string) (string, bool) {
+	if !Match(r.From, from) {
+		return "", false
+	}
+	if pattern, ok := matchAny(r.Deny, to); ok {
+		return fmt.Sprintf("%s may not depend on %s", r.From, pattern), true
+	}
+	if len(r.Allow) > 0 {
+		if _, ok := matchAny(r.Allow, to); !ok {
+			return fmt.Sprintf("%s may only depend on %s", r.From, strings.Join(r.Allow, ", ")), true
+		}
+	}
+	return "", false
+}
+
+func filesDependingOn(out *outline.Outline, from, to string) []string {
+	var files []string
+	for path, fi := range out.Files {
+		if fi.PackageDir == from && slices.Contains(fi.LocalPkgDeps, to) {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,174 @@
+package layering
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		pkg     string
+		want    bool
+	}{
+		{"internal/writer", "internal/writer", true},
+		{"internal/writer", "internal/writer/sub", false},
+		{"internal/writer", "internal/writers", false},
+		{"./internal/writer", "internal/writer", true},
+		{"internal/writer/", "internal/writer", true},
+		{"internal/...", "internal", true},
+		{"internal/...", "internal/writer", true},
+		{"internal/...", "internal/writer/sub", true},
+		{"internal/...", "internals/x", false},
+		{"internal/...", "cmd/internal", false},
+		{"./...", "anything/at/all", true},
+		{"...", ".", true},
+		{"cmd/*", "cmd/codebrev", true},
+		{"cmd/*", "cmd/codebrev/sub", false},
+		{"cmd/*", "cmd", false},
+		{"cmd/*/...", "cmd/codebrev/sub", true},
+		{"*/api", "billing/api", true},
+		{"internal/[ab]*", "internal/check", false},
+		{".", ".", true},
+		{".", "internal", false},
+		{"internal/[", "internal/[", false}, // malformed patterns never match
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.pkg); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{"deny", Rule{From: "internal/...", Deny: []string{"cmd/..."}}, false},
+		{"allow", Rule{From: "internal/outline", Allow: []string{"internal/gitignore"}}, false},
+		{"no from", Rule{Deny: []string{"cmd"}}, true},
+		{"no allow or deny", Rule{From: "internal"}, true},
+		{"absolute pattern", Rule{From: "/internal", Deny: []string{"cmd"}}, true},
+		{"empty pattern", Rule{From: "internal", Deny: []string{""}}, true},
+		{"malformed glob", Rule{From: "internal", Allow: []string{"internal/["}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	out := outline.New()
+	for _, dep := range [][2]string{
+		{"internal/outline", "internal/writer"}, // denied
+		{"internal/outline", "internal/gitignore"},
+		{"internal/writer", "internal/outline"},
+		{"internal/writer", "internal/parser"}, // not allowed
+		{"cmd/tool", "internal/writer"},
+		{".", "internal/outline"},
+	} {
+		out.AddPackageDependency(dep[0], dep[1])
+	}
+	// Edges only known from stats are checked too.
+	out.AddPackageEdgeStat("internal/gitignore", "cmd/tool", outline.EdgeStat{Calls: 2})
+	for path, deps := range map[string][]string{
+		"internal/outline/a.go": {"internal/writer"},
+		"internal/outline/b.go": {"internal/writer", "internal/gitignore"},
+		"internal/outline/c.go": nil,
+	} {
+		fi := out.AddFile(path, "/abs/"+path)
+		fi.PackageName = "outline"
+		fi.PackageDir = "internal/outline"
+		fi.LocalPkgDeps = deps
+	}
+
+	rules := []Rule{
+		{From: "internal/...", Deny: []string{"cmd/..."}},
+		{From: "internal/outline", Deny: []string{"internal/writer"}},
+		{From: "internal/writer", Allow: []string{"internal/outline"}},
+		{From: "internal/outline", Deny: []string{"internal/*"}}, // shadowed for writer by the rule above
+	}
+	got := Check(out, rules)
+
+	type edge struct{ from, to, reason string }
+	var edges []edge
+	for _, v := range got {
+		edges = append(edges, edge{v.From, v.To, v.Reason})
+	}
+	want := []edge{
+		{"internal/gitignore", "cmd/tool", "internal/... may not depend on cmd/..."},
+		{"internal/outline", "internal/gitignore", "internal/outline may not depend on internal/*"},
+		{"internal/outline", "internal/writer", "internal/outline may not depend on internal/writer"},
+		{"internal/writer", "internal/parser", "internal/writer may only depend on internal/outline"},
+	}
+	if !slices.Equal(edges, want) {
+		t.Fatalf("Check =\n%v\nwant\n%v", edges, want)
+	}
+
+	if files := got[2].Files; !slices.Equal(files, []string{"internal/outline/a.go", "internal/outline/b.go"}) {
+		t.Errorf("Files = %v, want the two files importing internal/writer", files)
+	}
+	if got[0].Stats.Calls != 2 {
+		t.Errorf("Stats = %+v, want the recorded edge stat", got[0].Stats)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		violations []Violation
+		want       string
+	}{
+		{"nil", nil, "[]\n"},
+		{"empty", []Violation{}, "[]\n"},
+		{"one", []Violation{{
+			From:   "internal/a",
+			To:     "cmd/<tool>",
+			Rule:   Rule{From: "internal/**", Deny: []string{"cmd/**"}},
+			Reason: "internal/** must not depend on cmd/**",
+			Files:  []string{"internal/a/a.go"},
+			Stats:  outline.EdgeStat{Imports: 1, Calls: 2},
+		}}, `[
+  {
+    "from": "internal/a",
+    "to": "cmd/<tool>",
+    "rule": {
+      "from": "internal/**",
+      "deny": [
+        "cmd/**"
+      ]
+    },
+    "reason": "internal/** must not depend on cmd/**",
+    "files": [
+      "internal/a/a.go"
+    ],
+    "stats": {
+      "imports": 1,
+      "calls": 2,
+      "typeUses": 0
+    }
+  }
+]
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteJSON(&buf, tt.violations); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteJSON =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
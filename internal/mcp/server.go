@@ -0,0 +1,199 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// ProtocolVersion is the MCP protocol revision this server implements.
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// maxMessageSize bounds a single newline-delimited request.
+const maxMessageSize = 16 * 1024 * 1024
+
+// BuildFunc produces a fresh outline for a scan root.
+type BuildFunc func(root string) (*outline.Outline, error)
+
+// Server is a Model Context Protocol server that exposes codebrev's analysis
+// as narrow tools over newline-delimited JSON-RPC 2.0 (the MCP stdio
+// transport). Requests are handled one at a time.
+type Server struct {
+	root    string
+	build   BuildFunc
+	version string
+	out     *outline.Outline // cached; built on first use and by generate_outline
+}
+
+// NewServer creates a server that analyzes root using build.
+func NewServer(root string, build BuildFunc, version string) *Server {
+	return &Server{root: root, build: build, version: version}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads requests from r and writes responses to w until r is
+// exhausted. Notifications (requests without an id) get no response.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := enc.Encode(errorResponse(json.RawMessage("null"), codeParseError, "parse error: "+err.Error())); err != nil {
+				return err
+			}
+			continue
+		}
+
+		resp := s.handle(&req)
+		if resp == nil {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *Server) handle(req *request) *response {
+	isNotification := len(req.ID) == 0
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if isNotification {
+			return nil
+		}
+		return errorResponse(req.ID, codeInvalidRequest, "invalid request")
+	}
+
+	var (
+		result any
+		rpcErr *rpcError
+	)
+	switch req.Method {
+	case "initialize":
+		result = s.initialize()
+	case "ping":
+		result = map[string]any{}
+	case "tools/list":
+		result = map[string]any{"tools": tools}
+	case "tools/call":
+		result, rpcErr = s.callTool(req.Params)
+	default:
+		// Notifications such as notifications/initialized need no handling.
+		rpcErr = &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+
+	if isNotification {
+		return nil
+	}
+	if rpcErr != nil {
+		return &response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// initialize answers with the single protocol revision we implement; the
+// client decides whether it can proceed.
+func (s *Server) initialize() map[string]any {
+	return map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities": map[string]any{
+			"tools": map[string]any{},
+		},
+		"serverInfo": map[string]any{
+			"name":    "codebrev",
+			"version": s.version,
+		},
+	}
+}
+
+func (s *Server) callTool(params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+
+	t, ok := findTool(p.Name)
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + p.Name}
+	}
+
+	args := p.Arguments
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	text, err := t.handler(s, args)
+	if err != nil {
+		// Tool failures are reported in the result so the model can see them.
+		return toolResult(err.Error(), true), nil
+	}
+	return toolResult(text, false), nil
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	result := map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+	}
+	if isError {
+		result["isError"] = true
+	}
+	return result
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	return &response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+// loadOutline returns the cached outline, building it on first use.
+func (s *Server) loadOutline() (*outline.Outline, error) {
+	if s.out != nil {
+		return s.out, nil
+	}
+	out, err := s.build(s.root)
+	if err != nil {
+		return nil, fmt.Errorf("build outline for %s: %w", s.root, err)
+	}
+	s.out = out
+	return out, nil
+}
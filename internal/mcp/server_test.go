@@ -0,0 +1,293 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/config"
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+	"github.com/jasonwillschiu/codebrev/internal/parser"
+)
+
+// newTestServer serves a small Go module: package a, and b importing it.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.23\n",
+		"a/a.go": "package a\n\n// A does a. It is called by B.\nfunc A() {}\n",
+		"b/b.go": "package b\n\nimport \"example.com/m/a\"\n\nfunc B() { a.A() }\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	build := func(dir string) (*outline.Outline, error) {
+		out := outline.New()
+		return out, parser.ProcessFiles(dir, out)
+	}
+	return NewServer(root, build, "test")
+}
+
+// message is a decoded JSON-RPC response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+// serve sends the newline-delimited requests to s and decodes every line it
+// writes back.
+func serve(t *testing.T, s *Server, requests ...string) []message {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Serve(strings.NewReader(strings.Join(requests, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	var msgs []message
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var m message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			t.Fatalf("response %q is not JSON: %v", scanner.Text(), err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+// toolText decodes a tools/call result.
+func toolText(t *testing.T, m message) (text string, isError bool) {
+	t.Helper()
+	if m.Error != nil {
+		t.Fatalf("tools/call failed: %+v", m.Error)
+	}
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(m.Result, &result); err != nil || len(result.Content) != 1 {
+		t.Fatalf("bad tool result %s: %v", m.Result, err)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func TestServeInitialize(t *testing.T) {
+	msgs := serve(t, newTestServer(t),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":"two","method":"ping"}`,
+	)
+	if len(msgs) != 2 {
+		t.Fatalf("got %d responses, want 2 (the notification gets none)", len(msgs))
+	}
+	if string(msgs[0].ID) != "1" || string(msgs[1].ID) != `"two"` {
+		t.Errorf("ids = %s, %s, want 1, \"two\"", msgs[0].ID, msgs[1].ID)
+	}
+	var result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	if err := json.Unmarshal(msgs[0].Result, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.ProtocolVersion != ProtocolVersion || result.ServerInfo.Name != "codebrev" || result.ServerInfo.Version != "test" {
+		t.Errorf("initialize = %s", msgs[0].Result)
+	}
+}
+
+func TestServeToolsList(t *testing.T) {
+	msgs := serve(t, newTestServer(t), `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	var result struct {
+		Tools []struct {
+			Name        string         `json:"name"`
+			InputSchema map[string]any `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(msgs[0].Result, &result); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+		if tool.InputSchema["type"] != "object" {
+			t.Errorf("%s input schema type = %v, want object", tool.Name, tool.InputSchema["type"])
+		}
+	}
+	want := "generate_outline get_file_context get_change_impact list_contracts get_package_graph"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("tools = %s, want %s", got, want)
+	}
+}
+
+func TestServeToolsCall(t *testing.T) {
+	clobbered := filepath.Join(t.TempDir(), "clobbered")
+	tests := []struct {
+		name      string
+		params    string
+		isError   bool
+		wantText  string
+		wantError string // JSON-RPC error message prefix, for protocol errors
+	}{
+		{
+			name:     "file context",
+			params:   `{"name":"get_file_context","arguments":{"file":"a/a.go"}}`,
+			wantText: "Used by: b/b.go",
+		},
+		{
+			name:     "change impact",
+			params:   `{"name":"get_change_impact","arguments":{"paths":["a"]}}`,
+			wantText: `"b"`,
+		},
+		{
+			name:     "package graph",
+			params:   `{"name":"get_package_graph","arguments":{"format":"mermaid"}}`,
+			wantText: "graph",
+		},
+		{
+			name:      "unknown tool",
+			params:    `{"name":"delete_everything","arguments":{}}`,
+			wantError: "unknown tool: delete_everything",
+		},
+		{
+			name:      "malformed params",
+			params:    `[1,2]`,
+			wantError: "invalid params",
+		},
+		{
+			name:     "wrong argument type",
+			params:   `{"name":"get_file_context","arguments":{"file":42}}`,
+			isError:  true,
+			wantText: "invalid arguments",
+		},
+		{
+			name:     "missing argument",
+			params:   `{"name":"get_file_context"}`,
+			isError:  true,
+			wantText: "file is required",
+		},
+		{
+			name:     "paths and since",
+			params:   `{"name":"get_change_impact","arguments":{"paths":["a"],"since":"main"}}`,
+			isError:  true,
+			wantText: "provide either paths or since",
+		},
+		{
+			name:     "option-like since",
+			params:   `{"name":"get_change_impact","arguments":{"since":"--output=` + clobbered + `"}}`,
+			isError:  true,
+			wantText: "must not start with '-'",
+		},
+		{
+			name:     "bad format",
+			params:   `{"name":"get_package_graph","arguments":{"format":"svg"}}`,
+			isError:  true,
+			wantText: `unknown format "svg"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := serve(t, newTestServer(t), `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":`+tt.params+`}`)
+			if len(msgs) != 1 || string(msgs[0].ID) != "7" {
+				t.Fatalf("responses = %+v, want one with id 7", msgs)
+			}
+			if tt.wantError != "" {
+				if msgs[0].Error == nil || msgs[0].Error.Code != codeInvalidParams || !strings.HasPrefix(msgs[0].Error.Message, tt.wantError) {
+					t.Fatalf("error = %+v, want invalid params %q", msgs[0].Error, tt.wantError)
+				}
+				return
+			}
+			text, isError := toolText(t, msgs[0])
+			if isError != tt.isError || !strings.Contains(text, tt.wantText) {
+				t.Errorf("result (isError %v) = %q, want isError %v containing %q", isError, text, tt.isError, tt.wantText)
+			}
+		})
+	}
+	if _, err := os.Stat(clobbered); err == nil {
+		t.Error("the since argument reached git as an option")
+	}
+}
+
+func TestServeFileContextDocs(t *testing.T) {
+	tests := []struct {
+		config  string
+		want    string
+		notWant string
+	}{
+		{"", "  A does a.\n", "It is called by B."},
+		{"docs: full\n", "  A does a. It is called by B.\n", ""},
+		{"docs: none\n", "", "A does a."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.config, func(t *testing.T) {
+			s := newTestServer(t)
+			if tt.config != "" {
+				if err := os.WriteFile(filepath.Join(s.root, config.FileName), []byte(tt.config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			msgs := serve(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_file_context","arguments":{"file":"a/a.go"}}}`)
+			text, isError := toolText(t, msgs[0])
+			if isError {
+				t.Fatalf("tool error: %s", text)
+			}
+			if !strings.Contains(text, tt.want) {
+				t.Errorf("missing %q in:\n%s", tt.want, text)
+			}
+			if tt.notWant != "" && strings.Contains(text, tt.notWant) {
+				t.Errorf("unexpected %q in:\n%s", tt.notWant, text)
+			}
+		})
+	}
+
+	s := newTestServer(t)
+	if err := os.WriteFile(filepath.Join(s.root, config.FileName), []byte("docs: some\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	msgs := serve(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_file_context","arguments":{"file":"a/a.go"}}}`)
+	if text, isError := toolText(t, msgs[0]); !isError || !strings.Contains(text, "invalid config") {
+		t.Errorf("bad docs mode: got %q (error %v), want an invalid config error", text, isError)
+	}
+}
+
+func TestServeProtocolErrors(t *testing.T) {
+	msgs := serve(t, newTestServer(t),
+		`{not json`,
+		`{"jsonrpc":"1.0","id":1,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","method":"resources/list"}`,
+		`{"jsonrpc":"2.0","method":"tools/call","params":{"name":"list_contracts"}}`,
+		``,
+	)
+	want := []struct {
+		id   string
+		code int
+	}{
+		{"null", codeParseError},
+		{"1", codeInvalidRequest},
+		{"2", codeMethodNotFound},
+	}
+	if len(msgs) != len(want) {
+		t.Fatalf("got %d responses, want %d (notifications get none)", len(msgs), len(want))
+	}
+	for i, w := range want {
+		if string(msgs[i].ID) != w.id || msgs[i].Error == nil || msgs[i].Error.Code != w.code {
+			t.Errorf("response %d = id %s, error %+v; want id %s, code %d", i, msgs[i].ID, msgs[i].Error, w.id, w.code)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/config"
+	"github.com/jasonwillschiu/codebrev/internal/impact"
+	"github.com/jasonwillschiu/codebrev/internal/mermaid"
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+	"github.com/jasonwillschiu/codebrev/internal/writer"
+)
+
+// tool is an MCP tool definition plus its handler. Handlers return the text
+// content of the result; an error becomes an isError result.
+type tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+
+	handler func(s *Server, args json.RawMessage) (string, error)
+}
+
+func findTool(name string) (tool, bool) {
+	for _, t := range tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return tool{}, false
+}
+
+var tools = []tool{
+	{
+		Name:        "generate_outline",
+		Description: "Parse the project (again) and refresh the cached outline. Optionally write the outline file the CLI would write: the output path and sections from .codebrev.yaml, or codebrev.md / codebrev.json in the project root. Returns a short summary, not the full outline.",
+		InputSchema: objectSchema(map[string]any{
+			"path":       stringProp("Directory to analyze; defaults to the server's root. Changes the root for later calls."),
+			"write_file": boolProp("Also write the outline file into the project root"),
+			"format":     enumProp("Output format when write_file is set", "markdown", "json"),
+		}),
+		handler: (*Server).toolGenerateOutline,
+	},
+	{
+		Name:        "get_file_context",
+		Description: "Return one file's section of the outline (functions, types, routes) plus its local dependencies and dependents.",
+		InputSchema: objectSchema(map[string]any{
+			"file": stringProp("File path, repo-relative or absolute"),
+		}, "file"),
+		handler: (*Server).toolGetFileContext,
+	},
+	{
+		Name:        "get_change_impact",
+		Description: "Blast radius for files or package directories, or for everything changed since a git ref: dependents with dependency paths and risk levels (JSON).",
+		InputSchema: objectSchema(map[string]any{
+			"paths": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Files or package directories to analyze",
+			},
+			"since": stringProp("Git ref; analyze files changed between it and HEAD instead of paths"),
+		}),
+		handler: (*Server).toolGetChangeImpact,
+	},
+	{
+		Name:        "list_contracts",
+		Description: "List contract surfaces: structs with json/query/form/header tags and router-style routes, with their users.",
+		InputSchema: objectSchema(map[string]any{}),
+		handler:     (*Server).toolListContracts,
+	},
+	{
+		Name:        "get_package_graph",
+		Description: "Package-level dependency graph with coupling signals (imports, calls, type uses) and risk levels.",
+		InputSchema: objectSchema(map[string]any{
+			"format": enumProp("json (default) or a mermaid diagram", "json", "mermaid"),
+		}),
+		handler: (*Server).toolGetPackageGraph,
+	},
+}
+
+func (s *Server) toolGenerateOutline(raw json.RawMessage) (string, error) {
+	var args struct {
+		Path      string `json:"path"`
+		WriteFile bool   `json:"write_file"`
+		Format    string `json:"format"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	if args.Format != "" && args.Format != "markdown" && args.Format != "json" {
+		return "", fmt.Errorf("unknown format %q", args.Format)
+	}
+
+	if args.Path != "" {
+		if info, err := os.Stat(args.Path); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("not a directory: %s", args.Path)
+		}
+		s.root = args.Path
+	}
+	s.out = nil
+	out, err := s.loadOutline()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Analyzed %s: %d files, %d packages, %d types.\n", s.root, len(out.Files), len(out.Packages), len(out.Types))
+
+	if args.WriteFile {
+		path, err := writeOutlineFile(out, s.root, args.Format, s.version)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "Wrote %s.\n", path)
+	}
+
+	pkgs := sortedKeys(out.Packages)
+	if len(pkgs) > 0 {
+		fmt.Fprintf(&sb, "Packages: %s\n", strings.Join(pkgs, ", "))
+	}
+	return sb.String(), nil
+}
+
+// writeOutlineFile writes the outline where and how `codebrev` run on root
+// would, following root's .codebrev.yaml, and returns the path written.
+func writeOutlineFile(out *outline.Outline, root, format, version string) (string, error) {
+	cfg, err := config.Discover(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid config: %v", err)
+	}
+
+	var buf bytes.Buffer
+	var path string
+	if format == "json" {
+		path = cfg.JSONOutputPath()
+		if path == "" {
+			path = filepath.Join(root, "codebrev.json")
+		}
+		err = writer.WriteOutlineJSON(&buf, out, "codebrev "+version)
+	} else {
+		path = cfg.OutputPath()
+		if path == "" {
+			path = filepath.Join(root, "codebrev.md")
+		}
+		opts := cfg.WriterOptions()
+		opts.Version = version
+		err = writer.WriteOutlineWithOptions(&buf, out, opts)
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func (s *Server) toolGetFileContext(raw json.RawMessage) (string, error) {
+	var args struct {
+		File string `json:"file"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	if args.File == "" {
+		return "", fmt.Errorf("file is required")
+	}
+
+	cfg, err := config.Discover(s.root)
+	if err != nil {
+		return "", fmt.Errorf("invalid config: %v", err)
+	}
+	out, err := s.loadOutline()
+	if err != nil {
+		return "", err
+	}
+	path, kind, err := impact.ResolveTarget(out, args.File)
+	if err != nil {
+		return "", err
+	}
+	if kind != impact.KindFile {
+		return "", fmt.Errorf("%s is a package, not a file", path)
+	}
+
+	var buf bytes.Buffer
+	if err := writer.WriteFileSection(&buf, out, path, cfg.WriterOptions().Docs); err != nil {
+		return "", err
+	}
+
+	deps := append([]string(nil), out.Dependencies[path]...)
+	sort.Strings(deps)
+	dependents := append([]string(nil), out.ReverseDeps[path]...)
+	sort.Strings(dependents)
+	risk := out.CalculateChangeImpact(path).RiskLevel
+
+	fmt.Fprintf(&buf, "Depends on: %s\n", listOrNone(deps))
+	fmt.Fprintf(&buf, "Used by: %s\n", listOrNone(dependents))
+	fmt.Fprintf(&buf, "Change risk: %s\n", risk)
+	return buf.String(), nil
+}
+
+func (s *Server) toolGetChangeImpact(raw json.RawMessage) (string, error) {
+	var args struct {
+		Paths []string `json:"paths"`
+		Since string   `json:"since"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	if (args.Since == "") == (len(args.Paths) == 0) {
+		return "", fmt.Errorf("provide either paths or since")
+	}
+	if args.Since != "" {
+		if err := impact.CheckRef(args.Since); err != nil {
+			return "", err
+		}
+	}
+
+	out, err := s.loadOutline()
+	if err != nil {
+		return "", err
+	}
+
+	var report any
+	if args.Since != "" {
+		changed, err := impact.GitChangedFiles(s.root, args.Since)
+		if err != nil {
+			return "", err
+		}
+		changes := impact.AnalyzeChanges(out, changed)
+		changes.Since = args.Since
+		report = changes
+	} else {
+		report, err = impact.Analyze(out, args.Paths)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := impact.WriteJSON(&buf, report); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (s *Server) toolListContracts(json.RawMessage) (string, error) {
+	out, err := s.loadOutline()
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := writer.WriteContracts(&buf, out); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// packageNode is one entry of the get_package_graph JSON result.
+type packageNode struct {
+	Package    string        `json:"package"`
+	Files      int           `json:"files"`
+	RiskLevel  string        `json:"riskLevel"`
+	DependsOn  []packageEdge `json:"dependsOn"`
+	Dependents []string      `json:"dependents"`
+}
+
+type packageEdge struct {
+	Package string `json:"package"`
+	outline.EdgeStat
+}
+
+func (s *Server) toolGetPackageGraph(raw json.RawMessage) (string, error) {
+	var args struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	out, err := s.loadOutline()
+	if err != nil {
+		return "", err
+	}
+
+	switch args.Format {
+	case "mermaid":
+		graph := mermaid.GenerateGoPackageDependencyGraph(out)
+		if graph == "" {
+			return "No Go packages found.", nil
+		}
+		return graph, nil
+	case "", "json":
+	default:
+		return "", fmt.Errorf("unknown format %q", args.Format)
+	}
+
+	nodes := []packageNode{}
+	for _, pkg := range sortedKeys(out.Packages) {
+		node := packageNode{
+			Package:    pkg,
+			Files:      len(out.Packages[pkg].Files),
+			RiskLevel:  out.CalculatePackageChangeImpact(pkg).RiskLevel,
+			DependsOn:  []packageEdge{},
+			Dependents: append([]string{}, out.PackageReverseDeps[pkg]...),
+		}
+		deps := append([]string(nil), out.PackageDeps[pkg]...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			node.DependsOn = append(node.DependsOn, packageEdge{Package: dep, EdgeStat: out.PackageEdgeStats[pkg][dep]})
+		}
+		sort.Strings(node.Dependents)
+		nodes = append(nodes, node)
+	}
+
+	data, err := json.MarshalIndent(map[string]any{"packages": nodes}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func objectSchema(props map[string]any, required ...string) map[string]any {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProp(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+func boolProp(description string) map[string]any {
+	return map[string]any{"type": "boolean", "description": description}
+}
+
+func enumProp(description string, values ...string) map[string]any {
+	return map[string]any{"type": "string", "enum": values, "description": description}
+}
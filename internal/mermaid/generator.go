@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/jasonwillschiu/codebrev/internal/outline"
@@ -460,6 +461,13 @@ func GenerateUnifiedDependencyMap(out *outline.Outline) string {
 	fileToNode := make(map[string]string)
 	fileCounter := 0
 
+	// Mermaid styles links by their position, so count every link written
+	// and remember those inside a dependency cycle.
+	links := 0
+	var cycleLinks []string
+	pkgCycle := cycleMembership(out.PackageCycles())
+	fileCycle := cycleMembership(out.FileCycles())
+
 	// Group packages by top-level directory.
 	topToPkgs := make(map[string][]string)
 	for _, pkg := range pkgs {
@@ -520,6 +528,7 @@ func GenerateUnifiedDependencyMap(out *outline.Outline) string {
 					fileLabel := disambiguatedFileLabel(filePath, baseCount)
 					sb.WriteString(fmt.Sprintf("        %s[\"%s\"]%s\n", fileID, fileLabel, fileStyle))
 					sb.WriteString(fmt.Sprintf("        %s --> %s\n", nodeID, fileID))
+					links++
 				}
 				continue
 			}
@@ -547,6 +556,7 @@ func GenerateUnifiedDependencyMap(out *outline.Outline) string {
 
 				// Connect package anchor to key file for discoverability.
 				sb.WriteString(fmt.Sprintf("            %s --> %s\n", nodeID, fileID))
+				links++
 			}
 			sb.WriteString("        end\n")
 		}
@@ -569,6 +579,10 @@ func GenerateUnifiedDependencyMap(out *outline.Outline) string {
 			strength := getPackageDependencyStrength(out, fromPkg, toPkg)
 			arrow := getArrowStyle(strength)
 			sb.WriteString(fmt.Sprintf("    %s %s %s\n", fromNode, arrow, toNode))
+			if inSameCycle(pkgCycle, fromPkg, toPkg) {
+				cycleLinks = append(cycleLinks, strconv.Itoa(links))
+			}
+			links++
 		}
 	}
 
@@ -594,6 +608,10 @@ func GenerateUnifiedDependencyMap(out *outline.Outline) string {
 			strength := getDependencyStrength(out, fromFile, dep)
 			arrow := getArrowStyle(strength)
 			sb.WriteString(fmt.Sprintf("    %s %s %s\n", fromNode, arrow, toNode))
+			if inSameCycle(fileCycle, fromFile, dep) {
+				cycleLinks = append(cycleLinks, strconv.Itoa(links))
+			}
+			links++
 		}
 	}
 
@@ -601,10 +619,28 @@ func GenerateUnifiedDependencyMap(out *outline.Outline) string {
 	sb.WriteString("    classDef highRisk fill:#ffcccc,stroke:#ff0000,stroke-width:2px\n")
 	sb.WriteString("    classDef mediumRisk fill:#fff3cd,stroke:#ffc107,stroke-width:2px\n")
 	sb.WriteString("    classDef lowRisk fill:#d4edda,stroke:#28a745,stroke-width:2px\n")
+	if len(cycleLinks) > 0 {
+		sb.WriteString(fmt.Sprintf("    linkStyle %s stroke:#d32f2f,stroke-width:3px\n", strings.Join(cycleLinks, ",")))
+	}
 	sb.WriteString("```\n")
 	return sb.String()
 }
 
+// cycleMembership maps each node in a cycle to its cycle's position + 1.
+func cycleMembership(cycles []outline.Cycle) map[string]int {
+	member := make(map[string]int)
+	for i, c := range cycles {
+		for _, n := range c.Nodes {
+			member[n] = i + 1
+		}
+	}
+	return member
+}
+
+func inSameCycle(member map[string]int, from, to string) bool {
+	return member[from] != 0 && member[from] == member[to]
+}
+
 func disambiguatedFileLabel(filePath string, baseCount map[string]int) string {
 	base := filepath.Base(filePath)
 	if baseCount[base] <= 1 {
@@ -0,0 +1,120 @@
+package outline
+
+import "sort"
+
+// Cycle is a strongly connected component of a dependency graph: every
+// node can reach every other through Edges. Self-dependencies count as a
+// cycle of one node.
+type Cycle struct {
+	Nodes []string    `json:"nodes"` // sorted
+	Edges []CycleEdge `json:"edges"` // dependencies between Nodes, sorted
+}
+
+// CycleEdge is one dependency inside a cycle. Stats are the coupling
+// signals for package edges; file edges have none.
+type CycleEdge struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Stats *EdgeStat `json:"stats,omitempty"`
+}
+
+// FileCycles returns the cycles in the file dependency graph
+// (Dependencies), ordered by their first node.
+func (o *Outline) FileCycles() []Cycle {
+	return findCycles(o.Dependencies, func(from, to string) *EdgeStat { return nil })
+}
+
+// PackageCycles returns the cycles in the Go package dependency graph
+// (PackageDeps), ordered by their first node.
+func (o *Outline) PackageCycles() []Cycle {
+	return findCycles(o.PackageDeps, func(from, to string) *EdgeStat {
+		stat := o.PackageEdgeStats[from][to]
+		return &stat
+	})
+}
+
+func findCycles(graph map[string][]string, stats func(from, to string) *EdgeStat) []Cycle {
+	var cycles []Cycle
+	for _, nodes := range stronglyConnected(graph) {
+		members := make(map[string]bool, len(nodes))
+		for _, n := range nodes {
+			members[n] = true
+		}
+
+		var c Cycle
+		for _, from := range nodes {
+			targets := append([]string(nil), graph[from]...)
+			sort.Strings(targets)
+			for i, to := range targets {
+				if !members[to] || (i > 0 && to == targets[i-1]) {
+					continue
+				}
+				c.Edges = append(c.Edges, CycleEdge{From: from, To: to, Stats: stats(from, to)})
+			}
+		}
+		// A single node is only a cycle if it depends on itself.
+		if len(nodes) > 1 || len(c.Edges) > 0 {
+			c.Nodes = nodes
+			cycles = append(cycles, c)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].Nodes[0] < cycles[j].Nodes[0] })
+	return cycles
+}
+
+// stronglyConnected returns the strongly connected components of graph
+// using Tarjan's algorithm, each with its nodes sorted. Nodes are visited
+// in sorted order so the result does not depend on map iteration.
+func stronglyConnected(graph map[string][]string) [][]string {
+	var nodes []string
+	for n := range graph {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(n string)
+	visit = func(n string) {
+		index[n] = len(index)
+		lowlink[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+
+		targets := append([]string(nil), graph[n]...)
+		sort.Strings(targets)
+		for _, m := range targets {
+			if _, seen := index[m]; !seen {
+				visit(m)
+				lowlink[n] = min(lowlink[n], lowlink[m])
+			} else if onStack[m] {
+				lowlink[n] = min(lowlink[n], index[m])
+			}
+		}
+
+		if lowlink[n] == index[n] {
+			var component []string
+			for {
+				m := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[m] = false
+				component = append(component, m)
+				if m == n {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+	for _, n := range nodes {
+		if _, seen := index[n]; !seen {
+			visit(n)
+		}
+	}
+	return components
+}
@@ -0,0 +1,93 @@
+package outline
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// cycleStrings renders cycles as "nodes: edges", e.g. "a b: a->b b->a".
+func cycleStrings(cycles []Cycle) []string {
+	var result []string
+	for _, c := range cycles {
+		var edges []string
+		for _, e := range c.Edges {
+			edges = append(edges, e.From+"->"+e.To)
+		}
+		result = append(result, strings.Join(c.Nodes, " ")+": "+strings.Join(edges, " "))
+	}
+	return result
+}
+
+func TestFileCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][2]string
+		want  []string
+	}{
+		{"no edges", nil, nil},
+		{"acyclic", [][2]string{{"a", "b"}, {"b", "c"}, {"a", "c"}}, nil},
+		{"self dependency", [][2]string{{"a", "a"}, {"a", "b"}}, []string{"a: a->a"}},
+		{"two nodes", [][2]string{{"b", "a"}, {"a", "b"}}, []string{"a b: a->b b->a"}},
+		{
+			"edges leaving the cycle are dropped",
+			[][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}, {"c", "d"}, {"x", "a"}},
+			[]string{"a b c: a->b b->c c->a"},
+		},
+		{
+			"chords inside the cycle are kept",
+			[][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}, {"a", "c"}},
+			[]string{"a b c: a->b a->c b->c c->a"},
+		},
+		{
+			"separate cycles ordered by first node",
+			[][2]string{{"y", "z"}, {"z", "y"}, {"c", "d"}, {"d", "c"}, {"d", "y"}},
+			[]string{"c d: c->d d->c", "y z: y->z z->y"},
+		},
+		{
+			"nested loops form one component",
+			[][2]string{{"a", "b"}, {"b", "a"}, {"b", "c"}, {"c", "d"}, {"d", "b"}},
+			[]string{"a b c d: a->b b->a b->c c->d d->b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Insert edges in both orders; the result must not change.
+			for _, edges := range [][][2]string{tt.edges, reversed(tt.edges)} {
+				o := New()
+				for _, e := range edges {
+					o.AddDependency(e[0], e[1])
+				}
+				if got := cycleStrings(o.FileCycles()); !slices.Equal(got, tt.want) {
+					t.Errorf("FileCycles = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPackageCycles(t *testing.T) {
+	o := New()
+	o.AddPackageDependency("internal/a", "internal/b")
+	o.AddPackageDependency("internal/b", "internal/a")
+	o.AddPackageDependency("internal/b", "internal/c")
+	o.AddPackageEdgeStat("internal/a", "internal/b", EdgeStat{Imports: 1, Calls: 3})
+
+	cycles := o.PackageCycles()
+	if got, want := cycleStrings(cycles), []string{"internal/a internal/b: internal/a->internal/b internal/b->internal/a"}; !slices.Equal(got, want) {
+		t.Fatalf("PackageCycles = %q, want %q", got, want)
+	}
+	edges := cycles[0].Edges
+	if edges[0].Stats == nil || *edges[0].Stats != (EdgeStat{Imports: 1, Calls: 3}) {
+		t.Errorf("a->b stats = %+v, want the recorded edge stat", edges[0].Stats)
+	}
+	if edges[1].Stats == nil || *edges[1].Stats != (EdgeStat{}) {
+		t.Errorf("b->a stats = %+v, want zero stats", edges[1].Stats)
+	}
+}
+
+func reversed(edges [][2]string) [][2]string {
+	result := slices.Clone(edges)
+	slices.Reverse(result)
+	return result
+}
@@ -0,0 +1,37 @@
+package outline
+
+import "fmt"
+
+// Diagnostic severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a problem found while scanning, such as a file that could
+// not be read or has syntax errors. The affected file may be missing from
+// the outline or only partly described.
+type Diagnostic struct {
+	File     string `json:"file"`             // repo-relative path
+	Line     int    `json:"line,omitempty"`   // 1-based; 0 when unknown
+	Column   int    `json:"column,omitempty"` // 1-based; 0 when unknown
+	Severity string `json:"severity"`         // SeverityError or SeverityWarning
+	Message  string `json:"message"`
+}
+
+// String formats the diagnostic as "file:line:col: severity: message".
+func (d Diagnostic) String() string {
+	pos := d.File
+	if d.Line > 0 {
+		pos += fmt.Sprintf(":%d", d.Line)
+		if d.Column > 0 {
+			pos += fmt.Sprintf(":%d", d.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, d.Severity, d.Message)
+}
+
+// AddDiagnostic records a scan problem.
+func (o *Outline) AddDiagnostic(d Diagnostic) {
+	o.Diagnostics = append(o.Diagnostics, d)
+}
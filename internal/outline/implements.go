@@ -0,0 +1,97 @@
+package outline
+
+// ResolveImplementations fills TypeInfo.Implements for every concrete Go
+// type with the keys of the interfaces it implements. A type implements an interface declared in the outline when its
+// methods, together with those promoted from embedded types, cover the
+// interface's methods (including those of embedded interfaces) with the
+// same signatures. Pointer and value receivers are not told apart.
+// Interfaces without methods, and interfaces embedding a type that is not in
+// the outline, are skipped since their method sets are not known.
+func (o *Outline) ResolveImplementations() {
+	keys := sortedKeys(o.Types)
+	SortTypeKeys(keys)
+	required := make(map[string]map[string]string)
+	var ifaces []string
+	for _, key := range keys {
+		ti := o.Types[key]
+		if ti == nil || ti.Kind != KindInterface {
+			continue
+		}
+		if methods, complete := o.methodSet(key, make(map[string]bool)); complete && len(methods) > 0 {
+			required[key] = methods
+			ifaces = append(ifaces, key)
+		}
+	}
+
+	for _, key := range keys {
+		ti := o.Types[key]
+		if ti == nil || !isConcreteKind(ti.Kind) {
+			continue
+		}
+		ti.Implements = nil
+		have, _ := o.methodSet(key, make(map[string]bool))
+		for _, iface := range ifaces {
+			if satisfies(have, required[iface]) {
+				ti.Implements = append(ti.Implements, iface)
+			}
+		}
+	}
+}
+
+// Implementers returns the keys of the types whose Implements lists the
+// interface key iface, sorted with SortTypeKeys.
+func (o *Outline) Implementers(iface string) []string {
+	var keys []string
+	for key, ti := range o.Types {
+		if ti != nil && containsString(ti.Implements, iface) {
+			keys = append(keys, key)
+		}
+	}
+	SortTypeKeys(keys)
+	return keys
+}
+
+func isConcreteKind(kind string) bool {
+	return kind == KindStruct || kind == KindNamed
+}
+
+// methodSet returns the method signatures of the type with the given key,
+// including methods promoted from embedded types; a type's own methods win
+// over promoted ones. complete is false when the type or one of its embedded
+// types is not declared in the outline.
+func (o *Outline) methodSet(key string, visiting map[string]bool) (methods map[string]string, complete bool) {
+	if visiting[key] {
+		return nil, true // embedding cycle; its methods are already counted
+	}
+	ti := o.Types[key]
+	if ti == nil || ti.Name == "" {
+		return nil, false
+	}
+	visiting[key] = true
+	defer delete(visiting, key)
+
+	methods = make(map[string]string, len(ti.MethodSignatures))
+	for method, sig := range ti.MethodSignatures {
+		methods[method] = sig
+	}
+	complete = true
+	for _, embedded := range ti.EmbeddedTypes {
+		promoted, ok := o.methodSet(embedded, visiting)
+		complete = complete && ok
+		for method, sig := range promoted {
+			if _, exists := methods[method]; !exists {
+				methods[method] = sig
+			}
+		}
+	}
+	return methods, complete
+}
+
+func satisfies(have, want map[string]string) bool {
+	for method, sig := range want {
+		if got, ok := have[method]; !ok || got != sig {
+			return false
+		}
+	}
+	return true
+}
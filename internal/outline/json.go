@@ -0,0 +1,187 @@
+package outline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// SchemaVersion is the version of the JSON document produced by NewDocument.
+// It is bumped whenever a field is removed, renamed or changes meaning;
+// purely additive fields do not bump it. See docs/json-schema.md.
+const SchemaVersion = 2
+
+// minSchemaVersion is the oldest schema version Decode reads. Version 1
+// keyed types by bare name, which TypeKey-based lookups cannot use.
+const minSchemaVersion = 2
+
+// Document is the versioned JSON representation of an Outline.
+// The Outline fields are embedded at the top level next to schemaVersion.
+type Document struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Generator     string `json:"generator,omitempty"` // e.g. "codebrev 0.12.1"
+	*Outline
+}
+
+// NewDocument wraps the outline for JSON serialization. Change impact is
+// calculated for every file and package first so consumers get the same
+// risk data the markdown writer reports.
+func NewDocument(o *Outline, generator string) *Document {
+	o.CalculateAllChangeImpact()
+	return &Document{
+		SchemaVersion: SchemaVersion,
+		Generator:     generator,
+		Outline:       o,
+	}
+}
+
+// CalculateAllChangeImpact fills ChangeImpact for every file and
+// PackageImpact for every known package.
+func (o *Outline) CalculateAllChangeImpact() {
+	for path := range o.Files {
+		o.CalculateChangeImpact(path)
+	}
+
+	pkgSet := make(map[string]bool)
+	for pkgPath := range o.Packages {
+		pkgSet[pkgPath] = true
+	}
+	for pkgPath := range o.PackageDeps {
+		pkgSet[pkgPath] = true
+	}
+	for pkgPath := range o.PackageReverseDeps {
+		pkgSet[pkgPath] = true
+	}
+	for pkgPath := range pkgSet {
+		o.CalculatePackageChangeImpact(pkgPath)
+	}
+}
+
+// Load reads a JSON document written by NewDocument from disk and rebuilds
+// the in-memory Outline.
+func Load(path string) (*Outline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	out, err := Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return out, nil
+}
+
+// Decode reads a JSON document and rebuilds the Outline. Derived indexes
+// (ReverseDeps, PackageReverseDeps and Packages) are recomputed from the
+// forward data rather than trusted from the document.
+func Decode(r io.Reader) (*Outline, error) {
+	doc := Document{Outline: New()}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode outline: %w", err)
+	}
+	if doc.SchemaVersion == 0 {
+		return nil, fmt.Errorf("missing schemaVersion; not a codebrev JSON outline")
+	}
+	if doc.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("unsupported schemaVersion %d (this build reads up to %d)", doc.SchemaVersion, SchemaVersion)
+	}
+	if doc.SchemaVersion < minSchemaVersion {
+		return nil, fmt.Errorf("schemaVersion %d is no longer supported (the oldest this build reads is %d); regenerate the outline", doc.SchemaVersion, minSchemaVersion)
+	}
+
+	doc.Outline.reindex()
+	return doc.Outline, nil
+}
+
+// reindex restores invariants after decoding: every map is non-nil and the
+// reverse/package indexes agree with Files, Dependencies and PackageDeps.
+func (o *Outline) reindex() {
+	fresh := New()
+	if o.ModulePaths == nil {
+		o.ModulePaths = fresh.ModulePaths
+	}
+	if o.Files == nil {
+		o.Files = fresh.Files
+	}
+	if o.Types == nil {
+		o.Types = fresh.Types
+	}
+	if o.Dependencies == nil {
+		o.Dependencies = fresh.Dependencies
+	}
+	if o.FunctionCalls == nil {
+		o.FunctionCalls = fresh.FunctionCalls
+	}
+	if o.TypeUsage == nil {
+		o.TypeUsage = fresh.TypeUsage
+	}
+	if o.PublicAPIs == nil {
+		o.PublicAPIs = fresh.PublicAPIs
+	}
+	if o.PackageDeps == nil {
+		o.PackageDeps = fresh.PackageDeps
+	}
+	if o.PackageEdgeStats == nil {
+		o.PackageEdgeStats = fresh.PackageEdgeStats
+	}
+	// Impact is recalculated on demand by the writers.
+	o.ChangeImpact = fresh.ChangeImpact
+	o.PackageImpact = fresh.PackageImpact
+
+	for path, fi := range o.Files {
+		if fi == nil {
+			delete(o.Files, path)
+			continue
+		}
+		if fi.Path == "" {
+			fi.Path = path
+		}
+	}
+	for name, ti := range o.Types {
+		if ti == nil {
+			delete(o.Types, name)
+		}
+	}
+
+	o.ReverseDeps = make(map[string][]string)
+	for _, from := range sortedKeys(o.Dependencies) {
+		for _, to := range o.Dependencies[from] {
+			o.AddReverseDependency(to, from)
+		}
+	}
+
+	o.PackageReverseDeps = make(map[string][]string)
+	for _, from := range sortedKeys(o.PackageDeps) {
+		for _, to := range o.PackageDeps[from] {
+			o.AddPackageReverseDependency(to, from)
+		}
+	}
+
+	o.Packages = make(map[string]*PackageInfo)
+	for _, filePath := range sortedKeys(o.Files) {
+		pkgPath := o.Files[filePath].PackageDir
+		if pkgPath == "" {
+			pkgPath = "."
+		}
+		pkg := o.Packages[pkgPath]
+		if pkg == nil {
+			// Files are visited in sorted order, so the first one is the representative.
+			pkg = &PackageInfo{PackagePath: pkgPath, Representative: filePath}
+			o.Packages[pkgPath] = pkg
+		}
+		pkg.Files = append(pkg.Files, filePath)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
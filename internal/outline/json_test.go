@@ -0,0 +1,121 @@
+package outline_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+	"github.com/jasonwillschiu/codebrev/internal/parser"
+	"github.com/jasonwillschiu/codebrev/internal/writer"
+)
+
+// parseTree outlines a small Go module with a TypeScript frontend.
+func parseTree(t *testing.T) *outline.Outline {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":   "module example.com/m\n\ngo 1.23\n",
+		"a/a.go":   "package a\n\n// Item is stored.\ntype Item struct {\n\tID string `json:\"id\"`\n}\n\nconst Max = 3\n\nfunc (i *Item) Save() error { return nil }\n",
+		"b/b.go":   "package b\n\nimport \"example.com/m/a\"\n\nfunc Use(i a.Item) { _ = i.Save() }\n",
+		"b/c.go":   "package b\n\nimport \"example.com/m/a\"\n\nvar Default = a.Item{}\n",
+		"web/x.ts": "import { y } from \"./y\";\n\nexport function x(): number { return y(); }\n",
+		"web/y.ts": "export function y(): number { return 1; }\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := outline.New()
+	if err := parser.ProcessFiles(root, out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	out := parseTree(t)
+	var doc, markdown bytes.Buffer
+	if err := writer.WriteOutlineJSON(&doc, out, "codebrev test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteOutlineWithOptions(&markdown, out, writer.Options{Version: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "codebrev.json")
+	if err := os.WriteFile(path, doc.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := outline.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc2, markdown2 bytes.Buffer
+	if err := writer.WriteOutlineJSON(&doc2, loaded, "codebrev test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteOutlineWithOptions(&markdown2, loaded, writer.Options{Version: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if doc2.String() != doc.String() {
+		t.Errorf("JSON changed after a round trip:\n%s\nwant\n%s", doc2.String(), doc.String())
+	}
+	if markdown2.String() != markdown.String() {
+		t.Errorf("markdown changed after a round trip:\n%s\nwant\n%s", markdown2.String(), markdown.String())
+	}
+
+	// Derived indexes are rebuilt rather than read.
+	if got := loaded.ReverseDeps["a/a.go"]; len(got) != 2 {
+		t.Errorf("ReverseDeps[a/a.go] = %v, want both files of package b", got)
+	}
+	if pkg := loaded.Packages["b"]; pkg == nil || len(pkg.Files) != 2 {
+		t.Errorf("Packages[b] = %+v, want two files", pkg)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{"malformed", `{"schemaVersion": 2, "files": [`, "decode outline"},
+		{"not an object", `[1, 2]`, "decode outline"},
+		{"wrong field type", `{"schemaVersion": "2"}`, "decode outline"},
+		{"no version", `{"files": {}}`, "missing schemaVersion"},
+		{"below the minimum", `{"schemaVersion": 1, "types": {"Item": {}}}`, "schemaVersion 1 is no longer supported"},
+		{"newer", `{"schemaVersion": 99}`, "unsupported schemaVersion 99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := outline.Decode(strings.NewReader(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Decode = %v, %v, want error %q", out, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeMinimal(t *testing.T) {
+	out, err := outline.Decode(strings.NewReader(`{"schemaVersion": 2, "files": {"a.go": {"packageDir": "a"}, "gone.go": null}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi := out.Files["a.go"]; fi == nil || fi.Path != "a.go" {
+		t.Errorf("Files[a.go] = %+v, want its path filled in", fi)
+	}
+	if _, ok := out.Files["gone.go"]; ok {
+		t.Error("null file entry was kept")
+	}
+	if out.Types == nil || out.Dependencies == nil || out.PackageEdgeStats == nil {
+		t.Error("maps missing from the document were left nil")
+	}
+}
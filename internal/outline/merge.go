@@ -0,0 +1,88 @@
+package outline
+
+// Merge folds a per-file fragment into o. A fragment is an Outline that a
+// parser filled for exactly one file; merging fragments in walk order leaves
+// o in the same state as parsing every file straight into it. Packages and
+// impact data are not merged; the parser's post-passes derive them from the
+// merged result.
+func (o *Outline) Merge(frag *Outline) {
+	for _, path := range sortedKeys(frag.Files) {
+		o.Files[path] = frag.Files[path]
+	}
+
+	for _, key := range sortedKeys(frag.Types) {
+		src := frag.Types[key]
+		if src == nil {
+			continue
+		}
+		dst := o.EnsureType(key)
+		if src.Name != "" {
+			dst.Name = src.Name
+			dst.IsPublic = src.IsPublic
+		}
+		if src.LineNumber != 0 {
+			dst.LineNumber = src.LineNumber
+		}
+		if len(src.TypeParams) > 0 {
+			dst.TypeParams = src.TypeParams
+		}
+		if src.Kind != "" {
+			dst.Kind = src.Kind
+		}
+		if src.Doc != "" {
+			dst.Doc = src.Doc
+		}
+		for _, method := range sortedKeys(src.MethodSignatures) {
+			if dst.MethodSignatures == nil {
+				dst.MethodSignatures = make(map[string]string)
+			}
+			dst.MethodSignatures[method] = src.MethodSignatures[method]
+		}
+		dst.Fields = append(dst.Fields, src.Fields...)
+		dst.EnumValues = append(dst.EnumValues, src.EnumValues...)
+		dst.Methods = append(dst.Methods, src.Methods...)
+		dst.Implements = append(dst.Implements, src.Implements...)
+		dst.EmbeddedTypes = append(dst.EmbeddedTypes, src.EmbeddedTypes...)
+		dst.UsedBy = append(dst.UsedBy, src.UsedBy...)
+		for _, key := range src.ContractKeys {
+			if !containsString(dst.ContractKeys, key) {
+				dst.ContractKeys = append(dst.ContractKeys, key)
+			}
+		}
+	}
+
+	o.Diagnostics = append(o.Diagnostics, frag.Diagnostics...)
+	o.Vars = append(o.Vars, frag.Vars...)
+	o.Funcs = append(o.Funcs, frag.Funcs...)
+
+	for _, caller := range sortedKeys(frag.FunctionCalls) {
+		for _, callee := range frag.FunctionCalls[caller] {
+			o.AddFunctionCall(caller, callee)
+		}
+	}
+	for _, typeName := range sortedKeys(frag.TypeUsage) {
+		for _, usedBy := range frag.TypeUsage[typeName] {
+			o.AddTypeUsage(typeName, usedBy)
+		}
+	}
+	for _, path := range sortedKeys(frag.PublicAPIs) {
+		o.PublicAPIs[path] = append(o.PublicAPIs[path], frag.PublicAPIs[path]...)
+	}
+	for _, from := range sortedKeys(frag.Dependencies) {
+		for _, to := range frag.Dependencies[from] {
+			o.AddDependency(from, to)
+		}
+	}
+
+	for _, fromPkg := range sortedKeys(frag.PackageDeps) {
+		for _, toPkg := range frag.PackageDeps[fromPkg] {
+			o.AddPackageDependency(fromPkg, toPkg)
+		}
+	}
+	for _, fromPkg := range sortedKeys(frag.PackageEdgeStats) {
+		stats := frag.PackageEdgeStats[fromPkg]
+		for _, toPkg := range sortedKeys(stats) {
+			o.AddPackageEdgeStat(fromPkg, toPkg, stats[toPkg])
+		}
+	}
+}
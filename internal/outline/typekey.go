@@ -0,0 +1,82 @@
+package outline
+
+import (
+	"sort"
+	"strings"
+)
+
+// TypeKey returns the key of a type in Outline.Types: the scope declaring
+// it and its name joined by a colon, e.g. "internal/server:Config". Go types
+// are scoped by package directory and TypeScript types by file, so types
+// sharing a name in different packages or files stay separate.
+func TypeKey(scope, name string) string {
+	return scope + ":" + name
+}
+
+// SplitTypeKey splits a key made by TypeKey into its scope and type name.
+// A string without a scope is returned as the name.
+func SplitTypeKey(key string) (scope, name string) {
+	i := strings.LastIndex(key, ":")
+	if i < 0 {
+		return "", key
+	}
+	return key[:i], key[i+1:]
+}
+
+// TypeScope returns the scope of the types declared in fi: its package
+// directory for Go files and its path for other files.
+func TypeScope(fi *FileInfo) string {
+	if fi.PackageName != "" {
+		return fi.PackageDir
+	}
+	return fi.Path
+}
+
+// FileType returns the type named name that fi declares, or nil.
+func (o *Outline) FileType(fi *FileInfo, name string) *TypeInfo {
+	return o.Types[TypeKey(TypeScope(fi), name)]
+}
+
+// TypeNames returns a function rendering type keys for display: the bare
+// name when no other type in the outline has it, the full key otherwise.
+// Strings that are not keys of o.Types, such as "io.Reader", are returned
+// unchanged.
+func (o *Outline) TypeNames() func(key string) string {
+	count := make(map[string]int, len(o.Types))
+	for key := range o.Types {
+		_, name := SplitTypeKey(key)
+		count[name]++
+	}
+	return func(key string) string {
+		if _, ok := o.Types[key]; !ok {
+			return key
+		}
+		if _, name := SplitTypeKey(key); count[name] == 1 {
+			return name
+		}
+		return key
+	}
+}
+
+// SortTypeKeys sorts type keys by type name, then by scope, so listings read
+// alphabetically whatever package the types come from.
+func SortTypeKeys(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		si, ni := SplitTypeKey(keys[i])
+		sj, nj := SplitTypeKey(keys[j])
+		if ni != nj {
+			return ni < nj
+		}
+		return si < sj
+	})
+}
+
+// PruneTypeUsage drops the TypeUsage entries of types that are not declared
+// in the outline, such as built-in and external types.
+func (o *Outline) PruneTypeUsage() {
+	for key := range o.TypeUsage {
+		if o.Types[key] == nil {
+			delete(o.TypeUsage, key)
+		}
+	}
+}
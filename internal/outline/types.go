@@ -3,108 +3,165 @@ package outline
 // PackageInfo represents a Go package (directory) within the scanned project.
 // PackagePath is repo-relative (e.g. "internal/parser" or ".").
 type PackageInfo struct {
-	PackagePath    string
-	Files          []string // repo-relative file paths
-	Representative string   // a stable file path used for visualization
+	PackagePath    string   `json:"packagePath"`
+	Files          []string `json:"files"`          // repo-relative file paths
+	Representative string   `json:"representative"` // a stable file path used for visualization
 }
 
 // EdgeStat represents aggregated coupling signals between two packages.
 type EdgeStat struct {
-	Imports  int
-	Calls    int
-	TypeUses int
+	Imports  int `json:"imports"`
+	Calls    int `json:"calls"`
+	TypeUses int `json:"typeUses"`
 }
 
 // Outline represents the complete code structure analysis
 type Outline struct {
-	RootDir string // absolute path to the scan root
+	RootDir string `json:"rootDir"` // absolute path to the scan root
 	// ModulePath is kept for backward compatibility (single-module repos).
 	// For go.work and multi-module workspaces, use ModulePaths.
-	ModulePath string
+	ModulePath string `json:"modulePath"`
 	// ModulePaths maps repo-relative module directories to their Go module path,
 	// e.g. "." -> "github.com/acme/repo", "server" -> "dmca-bot1-server".
-	ModulePaths map[string]string
-
-	Files         map[string]*FileInfo
-	Types         map[string]*TypeInfo
-	Vars          []string
-	Funcs         []string
-	Dependencies  map[string][]string    // file -> list of files it depends on
-	FunctionCalls map[string][]string    // function -> called functions
-	TypeUsage     map[string][]string    // type -> files that use it
-	ReverseDeps   map[string][]string    // file -> files that depend on it
-	PublicAPIs    map[string][]string    // file -> public functions/types
-	ChangeImpact  map[string]*ImpactInfo // file -> impact analysis
+	ModulePaths map[string]string `json:"modulePaths"`
+
+	Files         map[string]*FileInfo   `json:"files"`
+	Types         map[string]*TypeInfo   `json:"types"` // TypeKey -> type
+	Vars          []string               `json:"vars"`
+	Funcs         []string               `json:"funcs"`
+	Dependencies  map[string][]string    `json:"dependencies"`  // file -> list of files it depends on
+	FunctionCalls map[string][]string    `json:"functionCalls"` // function -> called functions
+	TypeUsage     map[string][]string    `json:"typeUsage"`     // TypeKey -> functions ("path:Func") that use it
+	ReverseDeps   map[string][]string    `json:"reverseDeps"`   // file -> files that depend on it
+	PublicAPIs    map[string][]string    `json:"publicAPIs"`    // file -> public functions/types
+	ChangeImpact  map[string]*ImpactInfo `json:"changeImpact"`  // file -> impact analysis
 
 	// Go package-level relationships (repo-relative package paths).
-	Packages           map[string]*PackageInfo
-	PackageDeps        map[string][]string            // package -> packages it depends on
-	PackageReverseDeps map[string][]string            // package -> packages that depend on it
-	PackageImpact      map[string]*ImpactInfo         // package -> impact analysis
-	PackageEdgeStats   map[string]map[string]EdgeStat // fromPkg -> toPkg -> stats
+	Packages           map[string]*PackageInfo        `json:"packages"`
+	PackageDeps        map[string][]string            `json:"packageDeps"`        // package -> packages it depends on
+	PackageReverseDeps map[string][]string            `json:"packageReverseDeps"` // package -> packages that depend on it
+	PackageImpact      map[string]*ImpactInfo         `json:"packageImpact"`      // package -> impact analysis
+	PackageEdgeStats   map[string]map[string]EdgeStat `json:"packageEdgeStats"`   // fromPkg -> toPkg -> stats
+
+	// RiskMedium and RiskHigh override the dependent counts above which a
+	// change is rated medium or high risk; zero means the default.
+	RiskMedium int `json:"riskMedium,omitempty"`
+	RiskHigh   int `json:"riskHigh,omitempty"`
+
+	// Diagnostics are the problems found while scanning, in walk order.
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
 }
 
+// Default risk thresholds used by RiskLevelFor.
+const (
+	DefaultRiskMedium = 3
+	DefaultRiskHigh   = 10
+)
+
 // FunctionInfo represents a function with its signature
 type FunctionInfo struct {
-	Name       string
-	Params     []string
-	ReturnType string
-	IsPublic   bool
-	CallsTo    []string // Functions this function calls
-	CalledBy   []string // Functions that call this function
-	UsesTypes  []string // Types this function uses
-	LineNumber int      // Line number in source file
+	Name       string   `json:"name"`
+	Params     []string `json:"params"`
+	ReturnType string   `json:"returnType"`
+	TypeParams []string `json:"typeParams,omitempty"` // Type parameters as "Name constraint"
+	Doc        string   `json:"doc,omitempty"`        // Doc comment text
+	IsPublic   bool     `json:"isPublic"`
+	CallsTo    []string `json:"callsTo"`    // Functions this function calls
+	CalledBy   []string `json:"calledBy"`   // Functions that call this function
+	UsesTypes  []string `json:"usesTypes"`  // Types this function uses
+	LineNumber int      `json:"lineNumber"` // Line number in source file
+	// Degraded is set when a syntax error damaged the signature; the
+	// damaged types render as "?".
+	Degraded bool `json:"degraded,omitempty"`
 }
 
 // FileInfo represents information about a single file
 type FileInfo struct {
-	Path       string // repo-relative
-	AbsPath    string // absolute (used for parsing/reading)
-	ModuleDir  string // repo-relative module directory (e.g. ".", "server")
-	ModulePath string // Go module path for this file's module; empty for non-Go files
-	PackageDir string // repo-relative directory (e.g. "internal/parser" or ".")
+	Path       string `json:"path"`       // repo-relative
+	AbsPath    string `json:"absPath"`    // absolute (used for parsing/reading)
+	ModuleDir  string `json:"moduleDir"`  // repo-relative module directory (e.g. ".", "server")
+	ModulePath string `json:"modulePath"` // Go module path for this file's module; empty for non-Go files
+	PackageDir string `json:"packageDir"` // repo-relative directory (e.g. "internal/parser" or ".")
 	// PackageName is the Go package name (e.g. "parser"); empty for non-Go files.
-	PackageName string
-
-	Functions     []FunctionInfo
-	Types         []string
-	Vars          []string
-	Routes        []string  // extracted route strings (best-effort)
-	Imports       []string  // external imports (packages/modules)
-	LocalDeps     []string  // local file dependencies (repo-relative file paths, resolved)
-	LocalPkgDeps  []string  // local Go package dependencies (repo-relative dirs)
-	ExportedFuncs []string  // Public functions
-	ExportedTypes []string  // Public types
-	TestCoverage  *TestInfo // Test coverage information
-	RiskLevel     string    // "low", "medium", "high" for change risk
+	PackageName string `json:"packageName"`
+
+	Functions     []FunctionInfo `json:"functions"`
+	Types         []string       `json:"types"`
+	Vars          []string       `json:"vars"`             // Exported package-level variable and constant names
+	Values        []ValueInfo    `json:"values,omitempty"` // Exported package-level constants and variables
+	Routes        []string       `json:"routes"`           // extracted route strings (best-effort)
+	Imports       []string       `json:"imports"`          // external imports (packages/modules)
+	LocalDeps     []string       `json:"localDeps"`        // local file dependencies (repo-relative file paths, resolved)
+	LocalPkgDeps  []string       `json:"localPkgDeps"`     // local Go package dependencies (repo-relative dirs)
+	ExportedFuncs []string       `json:"exportedFuncs"`    // Public functions
+	ExportedTypes []string       `json:"exportedTypes"`    // Public types
+	TestCoverage  *TestInfo      `json:"testCoverage"`     // Test coverage information
+	RiskLevel     string         `json:"riskLevel"`        // "low", "medium", "high" for change risk
+	// Degraded is set when the file had syntax errors and was described
+	// from a partial parse; declarations may be missing.
+	Degraded bool `json:"degraded,omitempty"`
 }
 
 // TypeInfo represents a type with its fields and methods
 type TypeInfo struct {
-	Name          string
-	Fields        []string
-	Methods       []string
-	IsPublic      bool
-	Implements    []string // Interfaces this type implements
-	EmbeddedTypes []string // Types this type embeds
-	ContractKeys  []string // e.g. "json:id", "query:q", "header:X-Token"
-	UsedBy        []string // Files/functions that use this type
-	LineNumber    int      // Line number in source file
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind,omitempty"` // One of the Kind constants; empty for non-Go types
+	Doc        string   `json:"doc,omitempty"`  // Doc comment text
+	Fields     []string `json:"fields"`
+	Methods    []string `json:"methods"`
+	TypeParams []string `json:"typeParams,omitempty"` // Type parameters as "Name constraint"
+	EnumValues []string `json:"enumValues,omitempty"` // Exported constants of this type declared in iota blocks
+	// MethodSignatures maps Go method names to their signatures without
+	// parameter names or package qualifiers, e.g. "([]byte) (int, error)".
+	MethodSignatures map[string]string `json:"methodSignatures,omitempty"`
+	IsPublic         bool              `json:"isPublic"`
+	Implements       []string          `json:"implements"`    // TypeKeys of interfaces this type implements
+	EmbeddedTypes    []string          `json:"embeddedTypes"` // TypeKeys of embedded types declared in the tree, others as written
+	ContractKeys     []string          `json:"contractKeys"`  // e.g. "json:id", "query:q", "header:X-Token"
+	UsedBy           []string          `json:"usedBy"`        // Files/functions that use this type
+	LineNumber       int               `json:"lineNumber"`    // Line number in source file
+}
+
+// ValueInfo describes an exported package-level Go constant or variable.
+type ValueInfo struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`           // ValueConst or ValueVar
+	Type string `json:"type,omitempty"` // declared type, if any
+	// Value is the constant's value as Go source (iota blocks evaluated),
+	// or the initializer's source text when it cannot be evaluated.
+	// Omitted for long initializers.
+	Value string `json:"value,omitempty"`
+	Doc   string `json:"doc,omitempty"`
 }
 
+// Kinds of ValueInfo.
+const (
+	ValueConst = "const"
+	ValueVar   = "var"
+)
+
+// Kinds of Go type declarations recorded in TypeInfo.Kind.
+const (
+	KindStruct     = "struct"
+	KindInterface  = "interface"
+	KindConstraint = "constraint" // interface with type terms, usable only as a constraint
+	KindAlias      = "alias"      // type A = B
+	KindNamed      = "named"      // any other defined type, e.g. type ID int
+)
+
 // ImpactInfo represents change impact analysis
 type ImpactInfo struct {
-	DirectDependents   []string // Files directly affected
-	IndirectDependents []string // Files indirectly affected
-	RiskLevel          string   // "low", "medium", "high"
-	TestsAffected      []string // Test files that need to run
+	DirectDependents   []string `json:"directDependents"`   // Files directly affected
+	IndirectDependents []string `json:"indirectDependents"` // Files indirectly affected
+	RiskLevel          string   `json:"riskLevel"`          // "low", "medium", "high"
+	TestsAffected      []string `json:"testsAffected"`      // Test files that need to run
 }
 
 // TestInfo represents test coverage information
 type TestInfo struct {
-	TestFiles     []string // Associated test files
-	Coverage      float64  // Coverage percentage
-	TestScenarios []string // Key test scenarios
+	TestFiles     []string `json:"testFiles"`     // Associated test files
+	Coverage      float64  `json:"coverage"`      // Coverage percentage
+	TestScenarios []string `json:"testScenarios"` // Key test scenarios
 }
 
 // New creates a new Outline instance
@@ -128,13 +185,14 @@ func New() *Outline {
 	}
 }
 
-// EnsureType ensures a type exists in the outline and returns it
-func (o *Outline) EnsureType(name string) *TypeInfo {
-	if t, ok := o.Types[name]; ok {
+// EnsureType ensures a type exists in the outline and returns it. key is
+// the type's TypeKey.
+func (o *Outline) EnsureType(key string) *TypeInfo {
+	if t, ok := o.Types[key]; ok {
 		return t
 	}
-	o.Types[name] = &TypeInfo{}
-	return o.Types[name]
+	o.Types[key] = &TypeInfo{}
+	return o.Types[key]
 }
 
 // AddFile adds a new file to the outline
@@ -216,11 +274,7 @@ func (o *Outline) CalculatePackageChangeImpact(packagePath string) *ImpactInfo {
 	o.findIndirectPackageDependents(packagePath, visited, &impact.IndirectDependents)
 
 	totalDeps := len(impact.DirectDependents) + len(impact.IndirectDependents)
-	if totalDeps > 10 {
-		impact.RiskLevel = "high"
-	} else if totalDeps > 3 {
-		impact.RiskLevel = "medium"
-	}
+	impact.RiskLevel = o.RiskLevelFor(totalDeps)
 
 	o.PackageImpact[packagePath] = impact
 	return impact
@@ -291,17 +345,15 @@ func (o *Outline) CalculateChangeImpact(filePath string) *ImpactInfo {
 
 	// Determine risk level based on number of dependents
 	totalDeps := len(impact.DirectDependents) + len(impact.IndirectDependents)
-	if totalDeps > 10 {
-		impact.RiskLevel = "high"
-	} else if totalDeps > 3 {
-		impact.RiskLevel = "medium"
-	}
+	impact.RiskLevel = o.RiskLevelFor(totalDeps)
 
 	o.ChangeImpact[filePath] = impact
 	return impact
 }
 
-// findIndirectDependents recursively finds indirect dependents
+// findIndirectDependents recursively finds indirect dependents. Files
+// reached again through a dependency cycle are skipped; FileCycles reports
+// the cycles themselves.
 func (o *Outline) findIndirectDependents(filePath string, visited map[string]bool, result *[]string) {
 	if visited[filePath] {
 		return
@@ -315,3 +367,21 @@ func (o *Outline) findIndirectDependents(filePath string, visited map[string]boo
 		}
 	}
 }
+
+// RiskLevelFor maps a dependent count to a risk level.
+func (o *Outline) RiskLevelFor(totalDeps int) string {
+	medium, high := DefaultRiskMedium, DefaultRiskHigh
+	if o.RiskMedium > 0 {
+		medium = o.RiskMedium
+	}
+	if o.RiskHigh > 0 {
+		high = o.RiskHigh
+	}
+
+	if totalDeps > high {
+		return "high"
+	} else if totalDeps > medium {
+		return "medium"
+	}
+	return "low"
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// cacheFormat is bumped whenever the parsers or the fragment layout change
+// in a way that makes existing entries wrong.
+const cacheFormat = 10
+
+// fileCache stores per-file parse fragments on disk. It is best-effort:
+// unreadable or corrupt entries are treated as misses and write failures
+// are ignored, so a broken cache only costs speed.
+type fileCache struct {
+	dir     string
+	version string
+	modules string // canonical ModulePaths; local import resolution depends on it
+}
+
+// cacheEntry is the on-disk form of one fragment.
+type cacheEntry struct {
+	Format   int              `json:"format"`
+	Fragment *outline.Outline `json:"fragment"`
+}
+
+func newFileCache(dir, version string, modulePaths map[string]string) *fileCache {
+	dirs := make([]string, 0, len(modulePaths))
+	for moduleDir := range modulePaths {
+		dirs = append(dirs, moduleDir)
+	}
+	sort.Strings(dirs)
+	var modules []byte
+	for _, d := range dirs {
+		modules = append(modules, d+"="+modulePaths[d]+"\n"...)
+	}
+	return &fileCache{dir: dir, version: version, modules: string(modules)}
+}
+
+// key identifies a file's parse result: anything that can change the
+// fragment goes into the hash.
+func (c *fileCache) key(relPath string, content []byte) string {
+	h := sha256.New()
+	for _, part := range []string{"codebrev-cache", c.version, relPath, c.modules} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *fileCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// load returns the cached fragment for key, or nil on a miss.
+func (c *fileCache) load(key string) *outline.Outline {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Format != cacheFormat || entry.Fragment == nil {
+		return nil
+	}
+	return entry.Fragment
+}
+
+// store writes frag under key via a temp file and rename, so concurrent
+// runs never observe a partial entry.
+func (c *fileCache) store(key string, frag *outline.Outline) {
+	// Module context is part of the key; don't repeat it in every entry.
+	trimmed := *frag
+	trimmed.RootDir = ""
+	trimmed.ModulePath = ""
+	trimmed.ModulePaths = nil
+
+	data, err := json.Marshal(cacheEntry{Format: cacheFormat, Fragment: &trimmed})
+	if err != nil {
+		return
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// writeTree creates files, keyed by slash-separated path, under a temporary
+// root and returns the root.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+var mergeTree = map[string]string{
+	"go.mod": "module example.com/m\n\ngo 1.23\n",
+	"a/a.go": `package a
+
+import "example.com/m/b"
+
+type Config struct{ B b.Item }
+
+func (c *Config) Load() error { return nil }
+
+func New() *Config { return &Config{} }
+`,
+	"a/a2.go": `package a
+
+const Max = 1 << 4
+
+func (c *Config) Save() { _ = New() }
+`,
+	"b/b.go": `package b
+
+type Item struct {
+	ID string ` + "`json:\"id\"`" + `
+}
+
+type Config struct{ Name string }
+
+func Use(i Item, c Config) {}
+`,
+	"b/broken.go": "package b\n\nfunc Broken( {\n",
+	"web/api.ts": `import { User } from "./types";
+
+export interface Client { get(id: string): User }
+
+export function fetchUser(id: string): User { return { id } as User; }
+`,
+	"web/types.ts": "export interface User { id: string }\n",
+}
+
+// outlineJSON parses root with opts and returns the outline as JSON, which
+// sorts map keys and so compares whole outlines.
+func outlineJSON(t *testing.T, root string, opts Options) string {
+	t.Helper()
+	out := outline.New()
+	if err := ProcessFilesWithOptions(root, out, opts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestProcessFilesDeterministic(t *testing.T) {
+	root := writeTree(t, mergeTree)
+	want := outlineJSON(t, root, Options{Workers: 1})
+
+	cacheDir := t.TempDir()
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"workers 2", Options{Workers: 2}},
+		{"workers 16", Options{Workers: 16}},
+		{"cold cache", Options{Workers: 8, CacheDir: cacheDir, Version: "v1"}},
+		{"warm cache", Options{Workers: 8, CacheDir: cacheDir, Version: "v1"}},
+		{"warm cache, one worker", Options{Workers: 1, CacheDir: cacheDir, Version: "v1"}},
+		{"other version", Options{Workers: 8, CacheDir: cacheDir, Version: "v2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 3 {
+				if got := outlineJSON(t, root, tt.opts); got != want {
+					t.Fatalf("outline differs from the single-worker parse:\n got %s\nwant %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFileCacheKey(t *testing.T) {
+	modules := map[string]string{".": "example.com/m"}
+	base := newFileCache("", "v1", modules).key("a.go", []byte("package a"))
+
+	tests := []struct {
+		name    string
+		version string
+		modules map[string]string
+		relPath string
+		content string
+		same    bool
+	}{
+		{"same inputs", "v1", modules, "a.go", "package a", true},
+		{"same modules, new map", "v1", map[string]string{".": "example.com/m"}, "a.go", "package a", true},
+		{"version", "v2", modules, "a.go", "package a", false},
+		{"path", "v1", modules, "b.go", "package a", false},
+		{"content", "v1", modules, "a.go", "package b", false},
+		{"module path", "v1", map[string]string{".": "example.com/other"}, "a.go", "package a", false},
+		{"extra module", "v1", map[string]string{".": "example.com/m", "tools": "example.com/tools"}, "a.go", "package a", false},
+		// Parts are separated, so shifting bytes between them changes the key.
+		{"path and content boundary", "v1", modules, "a.gop", "ackage a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := newFileCache("", tt.version, tt.modules).key(tt.relPath, []byte(tt.content))
+			if (key == base) != tt.same {
+				t.Errorf("key equal to base = %v, want %v", key == base, tt.same)
+			}
+		})
+	}
+}
+
+func TestFileCacheLoad(t *testing.T) {
+	c := newFileCache(t.TempDir(), "v1", nil)
+	frag := outline.New()
+	frag.AddFile("a.go", "/abs/a.go")
+	frag.RootDir = "/abs"
+
+	key := c.key("a.go", []byte("package a"))
+	if got := c.load(key); got != nil {
+		t.Fatal("load before store hit")
+	}
+	c.store(key, frag)
+	got := c.load(key)
+	if got == nil || got.Files["a.go"] == nil {
+		t.Fatalf("load after store = %+v, want the stored fragment", got)
+	}
+	if got.RootDir != "" {
+		t.Errorf("cached RootDir = %q, want it trimmed", got.RootDir)
+	}
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"corrupt", "{"},
+		{"old format", `{"format":1,"fragment":{}}`},
+		{"no fragment", `{"format":` + strconv.Itoa(cacheFormat) + `}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(c.path(key), []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if got := c.load(key); got != nil {
+				t.Errorf("load = %+v, want a miss", got)
+			}
+		})
+	}
+}
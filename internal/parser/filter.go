@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"path/filepath"
+
+	"github.com/jasonwillschiu/codebrev/internal/gitignore"
+)
+
+// Filter applies the Include, Exclude and Languages options to paths below
+// a scan root, on top of .gitignore. The watcher uses it to ignore changes
+// to files the parser would skip.
+type Filter struct {
+	absRoot string
+	include *gitignore.Matcher
+	exclude *gitignore.Matcher
+	exts    map[string]bool // enabled extensions; nil means all
+}
+
+// NewFilter returns the filter ProcessFilesWithOptions applies under
+// absRoot with opts.
+func NewFilter(absRoot string, opts Options) *Filter {
+	f := &Filter{
+		absRoot: absRoot,
+		include: gitignore.NewMatcher(opts.Include),
+		exclude: gitignore.NewMatcher(opts.Exclude),
+	}
+	if len(opts.Languages) > 0 {
+		f.exts = make(map[string]bool)
+		for _, lang := range opts.Languages {
+			for _, ext := range Languages[lang] {
+				f.exts[ext] = true
+			}
+		}
+	}
+	return f
+}
+
+// SkipFile reports whether the file at path is not parsed: it is not a
+// source file, or the filters rule it out.
+func (f *Filter) SkipFile(path string) bool {
+	return !IsSourceFile(path) || f.excluded(path)
+}
+
+// SkipDir reports whether the directory at path is excluded, so nothing
+// below it is parsed. The root itself is never skipped.
+func (f *Filter) SkipDir(path string) bool {
+	return path != f.absRoot && f.exclude.Match(toRepoRelativePath(f.absRoot, path))
+}
+
+// excluded reports whether the include/exclude filters and language
+// selection rule out the file at path.
+func (f *Filter) excluded(path string) bool {
+	return f.excludedRel(path, toRepoRelativePath(f.absRoot, path))
+}
+
+func (f *Filter) excludedRel(path, relPath string) bool {
+	if f.exts != nil && !f.exts[filepath.Ext(path)] {
+		return true
+	}
+	if f.exclude.Match(relPath) {
+		return true
+	}
+	return !f.include.Empty() && !f.include.Match(relPath)
+}
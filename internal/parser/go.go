@@ -1,32 +1,53 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
 	"go/ast"
 	"go/parser"
+	"go/scanner"
 	"go/token"
-	"log"
+	"go/types"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"slices"
 	"strings"
 
 	"github.com/jasonwillschiu/codebrev/internal/outline"
 )
 
-// parseGoFile parses a Go file using AST parsing
+// maxSyntaxDiagnostics caps the syntax errors reported per file; later
+// errors are usually fallout from the first ones.
+const maxSyntaxDiagnostics = 10
+
+// parseGoFile parses a Go file using AST parsing. A file with syntax errors
+// is still described from the partial AST the parser recovers, marked as
+// degraded, and its errors are recorded as diagnostics.
 func parseGoFile(path string, out *outline.Outline, fileInfo *outline.FileInfo, fset *token.FileSet) error {
-	file, err := parser.ParseFile(fset, path, nil, 0)
+	file, err := parser.ParseFile(fset, path, nil, parser.AllErrors|parser.ParseComments)
 	if err != nil {
-		log.Printf("parse %s: %v", path, err)
+		var syntaxErrs scanner.ErrorList
+		if !errors.As(err, &syntaxErrs) || file == nil {
+			return err
+		}
+		fileInfo.Degraded = true
+		addSyntaxDiagnostics(out, fileInfo.Path, syntaxErrs)
+	}
+	if file.Name == nil {
 		return nil
 	}
 
 	fileInfo.PackageName = file.Name.Name
+	scope := outline.TypeScope(fileInfo)
 
 	aliasToLocalPkgDir := make(map[string]string)
 
 	// Process imports first
 	for _, imp := range file.Imports {
+		if imp.Path == nil || imp.Path.Value == "" {
+			continue // broken import spec in a degraded file
+		}
 		importPath := strings.Trim(imp.Path.Value, "\"")
 		fileInfo.Imports = append(fileInfo.Imports, importPath)
 
@@ -47,21 +68,37 @@ func parseGoFile(path string, out *outline.Outline, fileInfo *outline.FileInfo,
 		}
 	}
 
+	// Constants and variables count only at package level; the inspection
+	// below also visits declarations inside function bodies.
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && (d.Tok == token.CONST || d.Tok == token.VAR) {
+			addGoValues(d, out, fileInfo)
+		}
+	}
+
 	ast.Inspect(file, func(n ast.Node) bool {
 		switch d := n.(type) {
 
-		// ---------- type/var/const blocks ----------
+		// ---------- type blocks ----------
 		case *ast.GenDecl:
 			switch d.Tok {
 			case token.TYPE: // structs, interfaces, etc.
 				for _, s := range d.Specs {
 					ts := s.(*ast.TypeSpec)
+					if ts.Name == nil || ts.Name.Name == "_" {
+						continue // name lost to a syntax error
+					}
 					typeName := ts.Name.Name
 					fileInfo.Types = append(fileInfo.Types, typeName)
 
-					ti := out.EnsureType(typeName)
+					ti := out.EnsureType(outline.TypeKey(scope, typeName))
 					ti.Name = typeName
 					ti.IsPublic = ast.IsExported(typeName)
+					ti.TypeParams = typeParamList(ts.TypeParams)
+					ti.Kind = typeKind(ts)
+					if doc := typeDoc(d, ts); doc != "" {
+						ti.Doc = doc
+					}
 
 					// Track public types
 					if ti.IsPublic {
@@ -84,26 +121,34 @@ func parseGoFile(path string, out *outline.Outline, fileInfo *outline.FileInfo,
 							}
 							// Track embedded types
 							if len(f.Names) == 0 { // anonymous field = embedded type
-								embeddedTypes := extractTypesFromExpr(f.Type)
-								ti.EmbeddedTypes = append(ti.EmbeddedTypes, embeddedTypes...)
+								if name := embeddedType(f.Type, scope, aliasToLocalPkgDir); name != "" {
+									ti.EmbeddedTypes = append(ti.EmbeddedTypes, name)
+								}
 							}
 						}
 					} else if it, ok := ts.Type.(*ast.InterfaceType); ok {
-						// Track interface methods
+						// Track interface methods and embedded interfaces
 						for _, method := range it.Methods.List {
 							if len(method.Names) > 0 {
 								ti.Methods = append(ti.Methods, method.Names[0].Name)
+								if ft, ok := method.Type.(*ast.FuncType); ok {
+									addMethodSignature(ti, method.Names[0].Name, ft)
+								}
+							} else if name := embeddedType(method.Type, scope, aliasToLocalPkgDir); name != "" {
+								ti.EmbeddedTypes = append(ti.EmbeddedTypes, name)
 							}
 						}
 					}
 				}
 
-				// Variables removed - not useful for LLM context
 			}
 
 		// ---------- functions ----------
 		case *ast.FuncDecl:
-			if d.Recv == nil { // plain function
+			if d.Name == nil || d.Name.Name == "_" || d.Type == nil {
+				return true // declaration mangled by a syntax error
+			}
+			if d.Recv == nil || len(d.Recv.List) == 0 { // plain function
 				funcInfo := extractFunctionInfo(d)
 				fileInfo.Functions = append(fileInfo.Functions, funcInfo)
 				out.Funcs = append(out.Funcs, funcInfo.Name)
@@ -121,15 +166,21 @@ func parseGoFile(path string, out *outline.Outline, fileInfo *outline.FileInfo,
 
 				// Track type usage
 				for _, typeName := range funcInfo.UsesTypes {
-					out.AddTypeUsage(typeName, fileInfo.Path+":"+funcInfo.Name)
+					if key := typeUsageKey(typeName, scope, aliasToLocalPkgDir); key != "" {
+						out.AddTypeUsage(key, fileInfo.Path+":"+funcInfo.Name)
+					}
 				}
 
 				// Package-level coupling signals (calls + type uses across local packages)
 				recordGoCouplingSignals(d, fileInfo, out, aliasToLocalPkgDir)
 			} else { // method with receiver
 				recv := receiverType(d.Recv.List[0].Type)
-				typeInfo := out.EnsureType(recv)
+				if recv == "" {
+					return true // receiver type lost to a syntax error
+				}
+				typeInfo := out.EnsureType(outline.TypeKey(scope, recv))
 				typeInfo.Methods = append(typeInfo.Methods, d.Name.Name)
+				addMethodSignature(typeInfo, d.Name.Name, d.Type)
 				typeInfo.Name = recv
 				typeInfo.IsPublic = ast.IsExported(recv)
 
@@ -145,7 +196,9 @@ func parseGoFile(path string, out *outline.Outline, fileInfo *outline.FileInfo,
 
 				// Track type usage for methods
 				for _, typeName := range funcInfo.UsesTypes {
-					out.AddTypeUsage(typeName, fileInfo.Path+":"+funcInfo.Name)
+					if key := typeUsageKey(typeName, scope, aliasToLocalPkgDir); key != "" {
+						out.AddTypeUsage(key, fileInfo.Path+":"+funcInfo.Name)
+					}
 				}
 
 				recordGoCouplingSignals(d, fileInfo, out, aliasToLocalPkgDir)
@@ -161,6 +214,30 @@ func parseGoFile(path string, out *outline.Outline, fileInfo *outline.FileInfo,
 	return nil
 }
 
+// addSyntaxDiagnostics records a file's syntax errors, one per line, up to
+// maxSyntaxDiagnostics.
+func addSyntaxDiagnostics(out *outline.Outline, relPath string, errs scanner.ErrorList) {
+	errs.Sort()
+	errs.RemoveMultiples()
+	for i, e := range errs {
+		if i == maxSyntaxDiagnostics {
+			out.AddDiagnostic(outline.Diagnostic{
+				File:     relPath,
+				Severity: outline.SeverityError,
+				Message:  fmt.Sprintf("%d more syntax errors", len(errs)-i),
+			})
+			break
+		}
+		out.AddDiagnostic(outline.Diagnostic{
+			File:     relPath,
+			Line:     e.Pos.Line,
+			Column:   e.Pos.Column,
+			Severity: outline.SeverityError,
+			Message:  e.Msg,
+		})
+	}
+}
+
 func resolveLocalGoImport(out *outline.Outline, importPath string) (string, bool) {
 	if len(out.ModulePaths) == 0 {
 		return "", false
@@ -344,6 +421,14 @@ func localPkgsUsedInTypeExpr(expr ast.Expr, aliasToLocalPkgDir map[string]string
 			walk(t.Value)
 		case *ast.Ellipsis:
 			walk(t.Elt)
+		case *ast.IndexExpr:
+			walk(t.X)
+			walk(t.Index)
+		case *ast.IndexListExpr:
+			walk(t.X)
+			for _, index := range t.Indices {
+				walk(index)
+			}
 		case *ast.FuncType:
 			if t.Params != nil {
 				for _, p := range t.Params.List {
@@ -365,11 +450,31 @@ func localPkgsUsedInTypeExpr(expr ast.Expr, aliasToLocalPkgDir map[string]string
 // extractFunctionInfo extracts function information from AST
 func extractFunctionInfo(d *ast.FuncDecl) outline.FunctionInfo {
 	funcInfo := outline.FunctionInfo{
-		Name:      d.Name.Name,
-		IsPublic:  ast.IsExported(d.Name.Name),
-		CallsTo:   []string{},
-		CalledBy:  []string{},
-		UsesTypes: []string{},
+		Name:       d.Name.Name,
+		IsPublic:   ast.IsExported(d.Name.Name),
+		TypeParams: typeParamList(d.Type.TypeParams),
+		Doc:        d.Doc.Text(),
+		CallsTo:    []string{},
+		CalledBy:   []string{},
+		UsesTypes:  []string{},
+		Degraded:   hasBadExpr(d.Type) || (d.Recv != nil && hasBadExpr(d.Recv)),
+	}
+
+	// Type parameters are not types the function depends on.
+	typeParams := make(map[string]bool)
+	if d.Type.TypeParams != nil {
+		for _, field := range d.Type.TypeParams.List {
+			for _, name := range field.Names {
+				typeParams[name.Name] = true
+			}
+		}
+	}
+	usesTypes := func(expr ast.Expr) {
+		for _, t := range extractTypesFromExpr(expr) {
+			if !typeParams[t] {
+				funcInfo.UsesTypes = append(funcInfo.UsesTypes, t)
+			}
+		}
 	}
 
 	// Extract parameters
@@ -377,7 +482,7 @@ func extractFunctionInfo(d *ast.FuncDecl) outline.FunctionInfo {
 		for _, param := range d.Type.Params.List {
 			paramType := typeToString(param.Type)
 			// Track type usage
-			funcInfo.UsesTypes = append(funcInfo.UsesTypes, extractTypesFromExpr(param.Type)...)
+			usesTypes(param.Type)
 
 			if len(param.Names) > 0 {
 				for _, name := range param.Names {
@@ -393,9 +498,12 @@ func extractFunctionInfo(d *ast.FuncDecl) outline.FunctionInfo {
 	if d.Type.Results != nil && len(d.Type.Results.List) > 0 {
 		var returnTypes []string
 		for _, result := range d.Type.Results.List {
-			returnTypes = append(returnTypes, typeToString(result.Type))
+			// (a, b int) declares two results of the same type.
+			for range max(len(result.Names), 1) {
+				returnTypes = append(returnTypes, typeToString(result.Type))
+			}
 			// Track type usage in return types
-			funcInfo.UsesTypes = append(funcInfo.UsesTypes, extractTypesFromExpr(result.Type)...)
+			usesTypes(result.Type)
 		}
 		funcInfo.ReturnType = strings.Join(returnTypes, ", ")
 	}
@@ -412,9 +520,9 @@ func extractFunctionInfo(d *ast.FuncDecl) outline.FunctionInfo {
 				}
 			case *ast.CompositeLit:
 				// Capture types used inside function bodies: Type{...}, &Type{...}, pkg.Type{...}, etc.
-				funcInfo.UsesTypes = append(funcInfo.UsesTypes, extractTypesFromExpr(call.Type)...)
+				usesTypes(call.Type)
 			case *ast.TypeAssertExpr:
-				funcInfo.UsesTypes = append(funcInfo.UsesTypes, extractTypesFromExpr(call.Type)...)
+				usesTypes(call.Type)
 			}
 			return true
 		})
@@ -423,37 +531,173 @@ func extractFunctionInfo(d *ast.FuncDecl) outline.FunctionInfo {
 	return funcInfo
 }
 
-// typeToString converts AST type expressions to strings
+// typeToString renders a type expression as Go source, e.g.
+// "func(ctx context.Context, opts ...Option) <-chan Result[T]". An
+// expression damaged by a syntax error renders as "?".
 func typeToString(expr ast.Expr) string {
+	if expr == nil {
+		return ""
+	}
+	if hasBadExpr(expr) {
+		return "?"
+	}
+	return types.ExprString(expr)
+}
+
+// hasBadExpr reports whether n contains an *ast.BadExpr, which the parser
+// leaves where a syntax error swallowed part of an expression.
+func hasBadExpr(n ast.Node) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if _, ok := n.(*ast.BadExpr); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// typeDoc returns the doc comment of a type declaration: the spec's own,
+// or the declaration's when it declares a single type.
+func typeDoc(d *ast.GenDecl, ts *ast.TypeSpec) string {
+	if ts.Doc != nil {
+		return ts.Doc.Text()
+	}
+	if len(d.Specs) == 1 {
+		return d.Doc.Text()
+	}
+	return ""
+}
+
+// typeKind classifies a type declaration as one of the outline.Kind values.
+func typeKind(ts *ast.TypeSpec) string {
+	if ts.Assign.IsValid() {
+		return outline.KindAlias
+	}
+	switch t := ts.Type.(type) {
+	case *ast.StructType:
+		return outline.KindStruct
+	case *ast.InterfaceType:
+		// Unions and ~T terms make an interface usable only as a constraint.
+		for _, elem := range t.Methods.List {
+			if len(elem.Names) == 0 && embeddedType(elem.Type, "", nil) == "" {
+				return outline.KindConstraint
+			}
+		}
+		return outline.KindInterface
+	}
+	return outline.KindNamed
+}
+
+// embeddedType returns the type an embedded struct field or interface
+// element refers to, without pointer or type arguments: its TypeKey when it
+// is declared in the file's package or a local package, otherwise its name
+// as written, e.g. "io.Reader" or "error"; "" for unions and ~T terms.
+func embeddedType(expr ast.Expr, scope string, aliasToLocalPkgDir map[string]string) string {
 	switch t := expr.(type) {
 	case *ast.Ident:
-		return t.Name
-	case *ast.StarExpr:
-		return "*" + typeToString(t.X)
-	case *ast.ArrayType:
-		return "[]" + typeToString(t.Elt)
-	case *ast.MapType:
-		return "map[" + typeToString(t.Key) + "]" + typeToString(t.Value)
+		if types.Universe.Lookup(t.Name) != nil {
+			return t.Name // error, any, comparable
+		}
+		return outline.TypeKey(scope, t.Name)
 	case *ast.SelectorExpr:
-		return typeToString(t.X) + "." + t.Sel.Name
-	case *ast.InterfaceType:
-		return "interface{}"
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			if dir, local := aliasToLocalPkgDir[pkg.Name]; local {
+				return outline.TypeKey(dir, t.Sel.Name)
+			}
+			return pkg.Name + "." + t.Sel.Name
+		}
+	case *ast.StarExpr:
+		return embeddedType(t.X, scope, aliasToLocalPkgDir)
+	case *ast.IndexExpr:
+		return embeddedType(t.X, scope, aliasToLocalPkgDir)
+	case *ast.IndexListExpr:
+		return embeddedType(t.X, scope, aliasToLocalPkgDir)
+	}
+	return ""
+}
+
+// typeUsageKey returns the TypeKey a type name from UsesTypes refers to:
+// unqualified names belong to the file's own package and names qualified
+// by an import of a local package to that package. Names qualified by any
+// other import cannot be declared in the tree and get "".
+func typeUsageKey(name, scope string, aliasToLocalPkgDir map[string]string) string {
+	pkg, typeName, qualified := strings.Cut(name, ".")
+	if !qualified {
+		return outline.TypeKey(scope, name)
+	}
+	if dir, ok := aliasToLocalPkgDir[pkg]; ok {
+		return outline.TypeKey(dir, typeName)
+	}
+	return ""
+}
+
+// qualifierRE matches package qualifiers in rendered type expressions.
+var qualifierRE = regexp.MustCompile(`\b[A-Za-z_][A-Za-z0-9_]*\.`)
+
+// addMethodSignature records a method's signature with parameter names and
+// package qualifiers dropped, e.g. "([]byte) (int, error)", so a method
+// declared in one package can be compared with an interface in another.
+func addMethodSignature(ti *outline.TypeInfo, name string, ft *ast.FuncType) {
+	fieldTypes := func(list *ast.FieldList) []string {
+		var types []string
+		if list == nil {
+			return types
+		}
+		for _, field := range list.List {
+			t := qualifierRE.ReplaceAllString(typeToString(field.Type), "")
+			for range max(len(field.Names), 1) {
+				types = append(types, t)
+			}
+		}
+		return types
+	}
+	sig := "(" + strings.Join(fieldTypes(ft.Params), ", ") + ")"
+	switch results := fieldTypes(ft.Results); len(results) {
+	case 0:
+	case 1:
+		sig += " " + results[0]
 	default:
-		return "unknown"
+		sig += " (" + strings.Join(results, ", ") + ")"
+	}
+	if ti.MethodSignatures == nil {
+		ti.MethodSignatures = make(map[string]string)
+	}
+	ti.MethodSignatures[name] = sig
+}
+
+// typeParamList renders a type parameter list as one "Name constraint"
+// entry per parameter; nil when there are none.
+func typeParamList(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var params []string
+	for _, field := range fields.List {
+		constraint := typeToString(field.Type)
+		for _, name := range field.Names {
+			params = append(params, name.Name+" "+constraint)
+		}
 	}
+	return params
 }
 
-// receiverType extracts the receiver type from a method
+// receiverType extracts the receiver type from a method; type arguments of
+// generic receivers are dropped, so (l *List[T]) yields "List".
 func receiverType(expr ast.Expr) string {
 	switch t := expr.(type) {
 	case *ast.StarExpr:
-		if id, ok := t.X.(*ast.Ident); ok {
-			return id.Name
-		}
+		return receiverType(t.X)
+	case *ast.ParenExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
 	case *ast.Ident:
 		return t.Name
 	}
-	return "???"
+	return "" // damaged by a syntax error
 }
 
 // extractTypesFromExpr extracts type names from AST expressions
@@ -461,7 +705,7 @@ func extractTypesFromExpr(expr ast.Expr) []string {
 	var types []string
 	switch t := expr.(type) {
 	case *ast.Ident:
-		if t.Name != "int" && t.Name != "string" && t.Name != "bool" && t.Name != "float64" {
+		if t.Name != "int" && t.Name != "string" && t.Name != "bool" && t.Name != "float64" && t.Name != "_" {
 			types = append(types, t.Name)
 		}
 	case *ast.StarExpr:
@@ -471,9 +715,31 @@ func extractTypesFromExpr(expr ast.Expr) []string {
 	case *ast.MapType:
 		types = append(types, extractTypesFromExpr(t.Key)...)
 		types = append(types, extractTypesFromExpr(t.Value)...)
-	case *ast.SelectorExpr:
+	case *ast.SelectorExpr: // qualified type: pkg.Type
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			types = append(types, pkg.Name+"."+t.Sel.Name)
+		}
+	case *ast.ChanType:
+		types = append(types, extractTypesFromExpr(t.Value)...)
+	case *ast.Ellipsis:
+		types = append(types, extractTypesFromExpr(t.Elt)...)
+	case *ast.IndexExpr: // generic instantiation: Result[T]
 		types = append(types, extractTypesFromExpr(t.X)...)
-		types = append(types, t.Sel.Name)
+		types = append(types, extractTypesFromExpr(t.Index)...)
+	case *ast.IndexListExpr:
+		types = append(types, extractTypesFromExpr(t.X)...)
+		for _, index := range t.Indices {
+			types = append(types, extractTypesFromExpr(index)...)
+		}
+	case *ast.FuncType:
+		for _, list := range []*ast.FieldList{t.Params, t.Results} {
+			if list == nil {
+				continue
+			}
+			for _, field := range list.List {
+				types = append(types, extractTypesFromExpr(field.Type)...)
+			}
+		}
 	}
 	return types
 }
@@ -0,0 +1,159 @@
+package parser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"testing"
+)
+
+func TestTypeToString(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"int", "int"},
+		{"*pkg.Type", "*pkg.Type"},
+		{"[]byte", "[]byte"},
+		{"[...]string", "[...]string"},
+		{"[4]int", "[4]int"},
+		{"map[string][]int", "map[string][]int"},
+		{"map[Key]*pkg.Value", "map[Key]*pkg.Value"},
+		{"chan int", "chan int"},
+		{"<-chan error", "<-chan error"},
+		{"chan<- struct{}", "chan<- struct{}"},
+		{"func()", "func()"},
+		{"func(int, string) error", "func(int, string) error"},
+		{"func(ctx context.Context) (int, error)", "func(ctx context.Context) (int, error)"},
+		{"func(...any) func() bool", "func(...any) func() bool"},
+		{"List[T]", "List[T]"},
+		{"Map[string, []int]", "Map[string, []int]"},
+		{"~int | ~string", "~int | ~string"},
+		{"interface{ M() }", "interface{M()}"},
+		{"struct{ A int }", "struct{A int}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := typeToString(expr); got != tt.want {
+				t.Errorf("typeToString(%s) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+	if got := typeToString(nil); got != "" {
+		t.Errorf("typeToString(nil) = %q, want empty", got)
+	}
+}
+
+func TestTypeParamList(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"type T struct{}", nil},
+		{"type T[E any] []E", []string{"E any"}},
+		{"type T[K comparable, V any] map[K]V", []string{"K comparable", "V any"}},
+		{"type T[A, B ~int | ~string] struct{}", []string{"A ~int | ~string", "B ~int | ~string"}},
+		{"type T[P interface{ ~[]E }, E fmt.Stringer] struct{}", []string{"P interface{~[]E}", "E fmt.Stringer"}},
+		{"type T[S ~[]E, E constraints.Ordered] func(S) E", []string{"S ~[]E", "E constraints.Ordered"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n\n"+tt.src+"\n", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			spec := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+			if got := typeParamList(spec.TypeParams); !slices.Equal(got, tt.want) {
+				t.Errorf("typeParamList = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedType(t *testing.T) {
+	local := map[string]string{"store": "internal/store"}
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"Base", "internal/a:Base"},
+		{"*Base", "internal/a:Base"},
+		{"List[T]", "internal/a:List"},
+		{"*Map[K, V]", "internal/a:Map"},
+		{"store.File", "internal/store:File"},
+		{"*store.Cache[string]", "internal/store:Cache"},
+		{"io.Reader", "io.Reader"},
+		{"*sync.Mutex", "sync.Mutex"},
+		{"error", "error"},
+		{"comparable", "comparable"},
+		{"~int", ""},
+		{"int | string", ""},
+		{"[]Base", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := embeddedType(expr, "internal/a", local); got != tt.want {
+				t.Errorf("embeddedType(%s) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGoFileDamagedSignatures(t *testing.T) {
+	tests := []struct {
+		src        string
+		wantParams []string
+		wantReturn string
+	}{
+		{"func F(a map[) {}", []string{"a ?"}, ""},
+		{"func F(a []) {}", []string{"a ?"}, ""},
+		{"func F(a *) {}", []string{"a ?"}, ""},
+		{"func F() map[string] {}", nil, "?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, fileInfo := parseGoSource(t, "package p\n\n"+tt.src+"\n")
+			if !fileInfo.Degraded {
+				t.Error("file not marked degraded")
+			}
+			if len(fileInfo.Functions) != 1 {
+				t.Fatalf("got %d functions, want 1", len(fileInfo.Functions))
+			}
+			f := fileInfo.Functions[0]
+			if !f.Degraded {
+				t.Error("function not marked degraded")
+			}
+			if !slices.Equal(f.Params, tt.wantParams) || f.ReturnType != tt.wantReturn {
+				t.Errorf("got params %q, return %q; want %q, %q", f.Params, f.ReturnType, tt.wantParams, tt.wantReturn)
+			}
+		})
+	}
+}
+
+func TestParseGoFileDamagedReceiver(t *testing.T) {
+	out, fileInfo := parseGoSource(t, "package p\n\nfunc (r *) M() {}\n\nfunc G() {}\n")
+	if len(fileInfo.Functions) != 1 || fileInfo.Functions[0].Name != "G" {
+		t.Errorf("functions = %+v, want only G", fileInfo.Functions)
+	}
+	if len(out.Types) != 0 {
+		t.Errorf("types = %v, want none", out.Types)
+	}
+}
+
+func TestParseGoFileDamagedValues(t *testing.T) {
+	_, fileInfo := parseGoSource(t, "package p\n\nvar V = [\n")
+	for _, v := range fileInfo.Values {
+		if v.Value != "" {
+			t.Errorf("%s has value %q, want none", v.Name, v.Value)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+func TestResolveImplementations(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.23\n",
+		"store/store.go": `package store
+
+import (
+	"context"
+	"fmt"
+)
+
+type Reader interface {
+	Read(ctx context.Context, p []byte) (int, error)
+}
+
+type Closer interface{ Close() error }
+
+type ReadCloser interface {
+	Reader
+	Closer
+}
+
+type Empty interface{}
+
+// Stringer embeds an interface outside the outline; its method set is unknown.
+type Stringer interface {
+	fmt.Stringer
+	Close() error
+}
+
+type File struct{}
+
+func (f *File) Read(ctx context.Context, p []byte) (int, error) { return 0, nil }
+
+func (f File) Close() error { return nil }
+
+type Wrapped struct{ *File }
+
+type Named int
+
+func (n Named) Close() error { return nil }
+
+type Wrong struct{}
+
+func (Wrong) Read(p []byte) (int, error) { return 0, nil }
+
+type Config struct{}
+
+func (Config) Close() error { return nil }
+`,
+		"other/other.go": `package other
+
+import (
+	stdctx "context"
+
+	"example.com/m/store"
+)
+
+type Config struct{}
+
+type Handle struct{ store.File }
+
+type Stamp struct{}
+
+func (Stamp) Read(c stdctx.Context, buf []byte) (n int, err error) { return 0, nil }
+`,
+	})
+	out := outline.New()
+	if err := ProcessFiles(root, out); err != nil {
+		t.Fatal(err)
+	}
+
+	closers := []string{"store:Closer"}
+	readers := []string{"store:Reader"}
+	all := []string{"store:Closer", "store:ReadCloser", "store:Reader"}
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		// Pointer and value receiver methods both count.
+		{"store:File", all},
+		// Methods promoted through an embedded pointer.
+		{"store:Wrapped", all},
+		// Promoted through a type embedded from another local package.
+		{"other:Handle", all},
+		{"store:Named", closers},
+		{"store:Config", closers},
+		// A same-named type in another package has its own method set.
+		{"other:Config", nil},
+		// Read(p []byte) does not match Read(ctx, p).
+		{"store:Wrong", nil},
+		// Parameter names and the stdctx qualifier are not part of the signature.
+		{"other:Stamp", readers},
+		// Interfaces do not implement interfaces.
+		{"store:ReadCloser", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			ti := out.Types[tt.key]
+			if ti == nil {
+				t.Fatalf("type %s not recorded", tt.key)
+			}
+			if !slices.Equal(ti.Implements, tt.want) {
+				t.Errorf("%s implements %v, want %v", tt.key, ti.Implements, tt.want)
+			}
+		})
+	}
+
+	if got, want := out.Implementers("store:ReadCloser"), []string{"store:File", "other:Handle", "store:Wrapped"}; !slices.Equal(got, want) {
+		t.Errorf("Implementers(ReadCloser) = %v, want %v", got, want)
+	}
+	for _, iface := range []string{"store:Empty", "store:Stringer"} {
+		if got := out.Implementers(iface); got != nil {
+			t.Errorf("Implementers(%s) = %v, want none: its method set is empty or unknown", iface, got)
+		}
+	}
+}
@@ -1,17 +1,72 @@
 package parser
 
 import (
+	"errors"
 	"go/token"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/jasonwillschiu/codebrev/internal/gitignore"
 	"github.com/jasonwillschiu/codebrev/internal/outline"
 )
 
+// Options tunes ProcessFilesWithOptions. The zero value parses every file.
+type Options struct {
+	// CacheDir, when set, stores each file's parse result keyed by its
+	// path, content hash and Version, and reuses it on later runs.
+	CacheDir string
+	// Version identifies the codebrev build; cache entries written by a
+	// different version are ignored.
+	Version string
+	// Workers is the number of files parsed concurrently; <= 0 means one
+	// per CPU. The result does not depend on it.
+	Workers int
+
+	// Include and Exclude are gitignore-style patterns relative to the scan
+	// root, applied on top of .gitignore. When Include is non-empty only
+	// matching files are parsed; excluded directories are not descended.
+	Include []string
+	Exclude []string
+	// Languages restricts parsing to these keys of Languages; empty means all.
+	Languages []string
+	// Aliases maps TS/JS import prefixes to repo-relative paths; nil means
+	// DefaultAliases.
+	Aliases map[string]string
+
+	// Analysis is AnalysisSyntax (the default when empty) or AnalysisTypes.
+	Analysis string
+
+	// Files, when non-nil, lists the files to parse instead of walking the
+	// root. Relative paths are resolved against the root. Files that are not
+	// source files or are ruled out by the filters above are skipped;
+	// .gitignore does not apply. Missing files are reported as diagnostics.
+	Files []string
+}
+
+// Languages maps the language names accepted in Options.Languages to the
+// file extensions they cover.
+var Languages = map[string][]string{
+	"go":         {".go"},
+	"typescript": {".ts", ".tsx"},
+	"javascript": {".js", ".jsx"},
+}
+
+// DefaultAliases resolves "~/..." imports to the src directory.
+var DefaultAliases = map[string]string{"~": "src"}
+
 // ProcessFiles processes all files in the given root directory
 func ProcessFiles(root string, out *outline.Outline) error {
+	return ProcessFilesWithOptions(root, out, Options{})
+}
+
+// ProcessFilesWithOptions is ProcessFiles with caching and other knobs.
+func ProcessFilesWithOptions(root string, out *outline.Outline, opts Options) error {
 	fset := token.NewFileSet()
 
 	absRoot, err := filepath.Abs(root)
@@ -32,70 +87,297 @@ func ProcessFiles(root string, out *outline.Outline) error {
 		out.ModulePath = modules[0].ModPath
 	}
 
+	fp := &fileParser{
+		fset:    fset,
+		absRoot: absRoot,
+		modules: modules,
+		base:    out,
+		filter:  NewFilter(absRoot, opts),
+	}
+	if opts.CacheDir != "" {
+		fp.cache = newFileCache(opts.CacheDir, opts.Version, out.ModulePaths)
+	}
+
 	// Load gitignore patterns
 	gitignoreRules := gitignore.New(absRoot)
 
 	// Check if root is a single file
-	if info, err := os.Stat(absRoot); err == nil && !info.IsDir() {
-		// Process single file
-		return processFile(absRoot, info, out, fset, absRoot, modules)
+	if info, err := os.Stat(absRoot); err == nil && !info.IsDir() && opts.Files == nil {
+		// Process single file; filters see the file relative to its directory.
+		if !IsSourceFile(absRoot) || fp.filter.excludedRel(absRoot, filepath.Base(absRoot)) {
+			return nil
+		}
+		out.Merge(fp.parse(absRoot))
+		out.PruneTypeUsage()
+		return nil
 	}
 
-	// Walk directory tree
-	err = filepath.Walk(absRoot, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+	// Walk directory tree, or parse the given files
+	var frags []*outline.Outline
+	if opts.Files != nil {
+		frags = fp.parseList(opts.Files, opts.Workers)
+	} else if frags, err = fp.parseTree(gitignoreRules, opts.Workers); err != nil {
+		return err
+	}
+	// Merging in walk order keeps the outline independent of worker timing.
+	for _, frag := range frags {
+		out.Merge(frag)
+	}
+	// Type usage is recorded for every name a function mentions; only types
+	// the tree declares are known once all fragments are merged.
+	out.PruneTypeUsage()
+	out.ResolveImplementations()
+	if opts.Analysis == AnalysisTypes {
+		resolveGoTypes(out, fset)
+	}
+
+	aliases := opts.Aliases
+	if aliases == nil {
+		aliases = DefaultAliases
+	}
+
+	// Second pass: resolve ~ alias dependencies now that all files are processed
+	if err := resolveAliasImports(out, aliases); err != nil {
+		return err
+	}
+
+	// Build package index and resolve Go package deps to representative files for file-level graphs/impact.
+	buildPackageIndexAndResolveGoDeps(out)
+	return nil
+}
+
+// fileParser holds the state shared by parse workers. Workers only read it;
+// each file is parsed into its own fragment outline.
+type fileParser struct {
+	fset    *token.FileSet // safe for concurrent use
+	absRoot string
+	modules []goModule
+	base    *outline.Outline // module context for fragments; not mutated while parsing
+	cache   *fileCache
+	filter  *Filter
+}
+
+// parsePool parses files on a pool of workers and collects one fragment per
+// file in the order they were added.
+type parsePool struct {
+	fp   *fileParser
+	jobs chan parseJob
+	wg   sync.WaitGroup
+
+	mu      sync.Mutex
+	results []*outline.Outline
+}
+
+type parseJob struct {
+	seq  int
+	path string
+}
+
+func (fp *fileParser) newPool(workers int) *parsePool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	p := &parsePool{fp: fp, jobs: make(chan parseJob, workers)}
+	for range workers {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for j := range p.jobs {
+				frag := fp.parse(j.path)
+				p.mu.Lock()
+				p.results[j.seq] = frag
+				p.mu.Unlock()
+			}
+		}()
+	}
+	return p
+}
+
+// add queues the file at path for parsing.
+func (p *parsePool) add(path string) {
+	p.mu.Lock()
+	seq := len(p.results)
+	p.results = append(p.results, nil)
+	p.mu.Unlock()
+	p.jobs <- parseJob{seq: seq, path: path}
+}
+
+// addFragment records an already built fragment, such as a diagnostic.
+func (p *parsePool) addFragment(frag *outline.Outline) {
+	p.mu.Lock()
+	p.results = append(p.results, frag)
+	p.mu.Unlock()
+}
+
+// wait stops the workers and returns the fragments in order.
+func (p *parsePool) wait() []*outline.Outline {
+	close(p.jobs)
+	p.wg.Wait()
+	return p.results
+}
+
+// parseTree walks the scan root and parses source files on a pool of
+// workers, returning one fragment per file in walk order. Files and
+// directories that cannot be read become fragments holding a diagnostic;
+// only a failure to walk the root itself is returned as an error.
+func (fp *fileParser) parseTree(rules *gitignore.Gitignore, workers int) ([]*outline.Outline, error) {
+	pool := fp.newPool(workers)
+	walkErr := filepath.Walk(fp.absRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil && path == fp.absRoot {
 			return err
 		}
 
 		// Check if path should be ignored
 		absPath, _ := filepath.Abs(path)
-		if gitignoreRules.ShouldIgnore(absPath) {
-			if info.IsDir() {
+		if rules.ShouldIgnore(absPath) {
+			if info != nil && info.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		return processFile(path, info, out, fset, absRoot, modules)
-	})
+		// An unreadable directory or file below the root is reported and
+		// skipped; Walk does not descend into a directory it cannot list.
+		if err != nil {
+			relPath := toRepoRelativePath(fp.absRoot, path)
+			if info != nil && info.IsDir() {
+				if fp.filter.SkipDir(path) {
+					return nil
+				}
+			} else if fp.filter.SkipFile(path) {
+				return nil
+			}
+			pool.addFragment(fp.failed(relPath, err))
+			return nil
+		}
+		if info.IsDir() {
+			if fp.filter.SkipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fp.filter.SkipFile(path) {
+			return nil
+		}
 
-	if err != nil {
-		return err
+		pool.add(path)
+		return nil
+	})
+	results := pool.wait()
+	if walkErr != nil {
+		return nil, walkErr
 	}
+	return results, nil
+}
 
-	// Second pass: resolve ~ alias dependencies now that all files are processed
-	if err := resolveAliasImports(out); err != nil {
-		return err
+// parseList parses the listed files on a pool of workers, returning one
+// fragment per file sorted by repo-relative path so the result does not
+// depend on the order of the list. Duplicates are parsed once.
+func (fp *fileParser) parseList(paths []string, workers int) []*outline.Outline {
+	byRel := make(map[string]string)
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(fp.absRoot, path)
+		}
+		path = filepath.Clean(path)
+		byRel[toRepoRelativePath(fp.absRoot, path)] = path
 	}
+	relPaths := make([]string, 0, len(byRel))
+	for relPath := range byRel {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
 
-	// Build package index and resolve Go package deps to representative files for file-level graphs/impact.
-	buildPackageIndexAndResolveGoDeps(out)
-	return nil
+	pool := fp.newPool(workers)
+	for _, relPath := range relPaths {
+		path := byRel[relPath]
+		if fp.filter.SkipFile(path) {
+			continue
+		}
+		if relPath == ".." || strings.HasPrefix(relPath, "../") {
+			pool.addFragment(fp.failed(relPath, errors.New("outside the scan root")))
+			continue
+		}
+		switch info, err := os.Stat(path); {
+		case err != nil:
+			pool.addFragment(fp.failed(relPath, err))
+		case info.IsDir():
+			pool.addFragment(fp.failed(relPath, errors.New("is a directory")))
+		default:
+			pool.add(path)
+		}
+	}
+	return pool.wait()
 }
 
-// processFile processes a single file based on its extension
-func processFile(path string, info os.FileInfo, out *outline.Outline, fset *token.FileSet, absRoot string, modules []goModule) error {
-	if info.IsDir() {
-		return nil
-	}
+// parse parses one source file into a fresh fragment, going through the
+// cache when one is configured. A file that cannot be read or parsed yields
+// a fragment with only a diagnostic.
+func (fp *fileParser) parse(path string) *outline.Outline {
+	relPath := toRepoRelativePath(fp.absRoot, path)
 
-	// Check for supported file extensions
-	supportedExts := []string{".go", ".js", ".jsx", ".ts", ".tsx"}
-	supported := false
-	for _, ext := range supportedExts {
-		if strings.HasSuffix(path, ext) {
-			supported = true
-			break
+	var key string
+	if fp.cache != nil {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fp.failed(relPath, err)
+		}
+		key = fp.cache.key(relPath, content)
+		if frag := fp.cache.load(key); frag != nil {
+			// The scan root may have moved since the entry was written.
+			if fileInfo := frag.Files[relPath]; fileInfo != nil {
+				fileInfo.AbsPath = path
+			}
+			return frag
 		}
 	}
 
-	// Skip test files and unsupported files
-	if !supported || strings.HasSuffix(path, "_test.go") || strings.Contains(path, ".test.") || strings.Contains(path, ".spec.") {
-		return nil
+	frag := newFragment(fp.base)
+	if err := parseFile(path, relPath, frag, fp.fset, fp.absRoot, fp.modules); err != nil {
+		return fp.failed(relPath, err)
+	}
+	if fp.cache != nil {
+		fp.cache.store(key, frag)
+	}
+	return frag
+}
+
+// failed returns a fragment that records err as an error diagnostic for
+// relPath and holds nothing else, so the file is left out of the outline.
+func (fp *fileParser) failed(relPath string, err error) *outline.Outline {
+	frag := newFragment(fp.base)
+	frag.AddDiagnostic(outline.Diagnostic{
+		File:     relPath,
+		Severity: outline.SeverityError,
+		Message:  diagnosticMessage(err),
+	})
+	return frag
+}
+
+// diagnosticMessage drops the absolute path from file system errors, which
+// the diagnostic already identifies, so output does not depend on where the
+// tree is checked out.
+func diagnosticMessage(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Op + ": " + pathErr.Err.Error()
 	}
+	return err.Error()
+}
+
+// newFragment returns an empty outline carrying the module context that
+// parsers consult while filling it.
+func newFragment(out *outline.Outline) *outline.Outline {
+	frag := outline.New()
+	frag.RootDir = out.RootDir
+	frag.ModulePath = out.ModulePath
+	frag.ModulePaths = out.ModulePaths
+	return frag
+}
 
+// parseFile parses one source file into out based on its extension.
+func parseFile(path, relPath string, out *outline.Outline, fset *token.FileSet, absRoot string, modules []goModule) error {
 	// Initialize file info
-	relPath := toRepoRelativePath(absRoot, path)
 	fileInfo := out.AddFile(relPath, path)
 	fileInfo.PackageDir = filepath.ToSlash(filepath.Dir(relPath))
 	if fileInfo.PackageDir == "" || fileInfo.PackageDir == "." {
@@ -115,6 +397,23 @@ func processFile(path string, info os.FileInfo, out *outline.Outline, fset *toke
 	return nil
 }
 
+// IsSourceFile reports whether ProcessFiles parses the file at path:
+// supported extensions only, test files excluded.
+func IsSourceFile(path string) bool {
+	// Check for supported file extensions
+	supportedExts := []string{".go", ".js", ".jsx", ".ts", ".tsx"}
+	supported := false
+	for _, ext := range supportedExts {
+		if strings.HasSuffix(path, ext) {
+			supported = true
+			break
+		}
+	}
+
+	// Skip test files and unsupported files
+	return supported && !strings.HasSuffix(path, "_test.go") && !strings.Contains(path, ".test.") && !strings.Contains(path, ".spec.")
+}
+
 func toRepoRelativePath(absRoot, absPath string) string {
 	rel, err := filepath.Rel(absRoot, absPath)
 	if err != nil {
@@ -148,8 +447,11 @@ func assignGoModuleForFile(fileInfo *outline.FileInfo, scanRootAbs, fileAbs stri
 }
 
 func buildPackageIndexAndResolveGoDeps(out *outline.Outline) {
+	filePaths := sortedFilePaths(out)
+
 	// Build packages and choose a representative file for each.
-	for filePath, fileInfo := range out.Files {
+	for _, filePath := range filePaths {
+		fileInfo := out.Files[filePath]
 		pkgPath := fileInfo.PackageDir
 		if pkgPath == "" {
 			pkgPath = "."
@@ -166,7 +468,8 @@ func buildPackageIndexAndResolveGoDeps(out *outline.Outline) {
 	}
 
 	// Resolve package deps to a single representative file to avoid graph explosion.
-	for filePath, fileInfo := range out.Files {
+	for _, filePath := range filePaths {
+		fileInfo := out.Files[filePath]
 		if len(fileInfo.LocalPkgDeps) == 0 {
 			continue
 		}
@@ -183,12 +486,23 @@ func buildPackageIndexAndResolveGoDeps(out *outline.Outline) {
 }
 
 // resolveAliasImports resolves ~ alias imports now that all files are processed
-func resolveAliasImports(out *outline.Outline) error {
-	for filePath, fileInfo := range out.Files {
+func resolveAliasImports(out *outline.Outline, aliases map[string]string) error {
+	for _, filePath := range sortedFilePaths(out) {
+		fileInfo := out.Files[filePath]
 		var resolvedDeps []string
 
-		for _, dep := range fileInfo.LocalDeps {
-			resolvedDep := resolveLocalImport(filePath, dep, out)
+		deps := fileInfo.LocalDeps
+		if hasKnownFrontendExtension(filePath) {
+			// Imports through a configured alias (e.g. "@/lib/api") are local too.
+			for _, imp := range fileInfo.Imports {
+				if _, ok := matchAlias(imp, aliases); ok && !slices.Contains(deps, imp) {
+					deps = append(deps, imp)
+				}
+			}
+		}
+
+		for _, dep := range deps {
+			resolvedDep := resolveLocalImport(filePath, dep, out, aliases)
 			if resolvedDep == "" {
 				continue
 			}
@@ -203,15 +517,37 @@ func resolveAliasImports(out *outline.Outline) error {
 	return nil
 }
 
-func resolveLocalImport(fromFile, dep string, out *outline.Outline) string {
+// sortedFilePaths returns the outline's file paths in a stable order so
+// post-passes produce the same dependency lists on every run.
+func sortedFilePaths(out *outline.Outline) []string {
+	paths := make([]string, 0, len(out.Files))
+	for path := range out.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// matchAlias returns the longest alias prefix of imp.
+func matchAlias(imp string, aliases map[string]string) (string, bool) {
+	best := ""
+	for prefix := range aliases {
+		if strings.HasPrefix(imp, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	return best, best != ""
+}
+
+func resolveLocalImport(fromFile, dep string, out *outline.Outline, aliases map[string]string) string {
 	// Strip query/hash fragments if present (frontend patterns).
 	if idx := strings.IndexAny(dep, "?#"); idx >= 0 {
 		dep = dep[:idx]
 	}
 
-	// Resolve "~" alias to "src".
-	if strings.HasPrefix(dep, "~") {
-		dep = strings.Replace(dep, "~", "src", 1)
+	// Resolve aliases, e.g. "~" to "src".
+	if prefix, ok := matchAlias(dep, aliases); ok {
+		dep = aliases[prefix] + strings.TrimPrefix(dep, prefix)
 	}
 
 	// Relative import: resolve against the importing file's directory.
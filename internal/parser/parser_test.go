@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+func TestProcessFilesList(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"go.mod":       "module example.com/m\n\ngo 1.23\n",
+		".gitignore":   "ignored/\n",
+		"a.go":         "package m\n\nfunc A() {}\n",
+		"b/b.go":       "package b\n\nfunc B() {}\n",
+		"ignored/i.go": "package ignored\n",
+		"web/app.ts":   "export function app() {}\n",
+		"README.md":    "# m\n",
+		"gen/g.go":     "package gen\n",
+		"odd.go/x.go":  "package odd\n",
+	})
+
+	tests := []struct {
+		name  string
+		files []string
+		opts  Options
+		want  []string // parsed files
+		diags []string // "file: message" of each diagnostic
+	}{
+		{name: "empty list", files: []string{}},
+		{name: "relative paths", files: []string{"b/b.go", "a.go"}, want: []string{"a.go", "b/b.go"}},
+		{name: "absolute path", files: []string{filepath.Join(root, "a.go")}, want: []string{"a.go"}},
+		{name: "duplicates", files: []string{"a.go", "./a.go", "b/../a.go"}, want: []string{"a.go"}},
+		{name: "non-source files skipped", files: []string{"README.md", "go.mod", "web/app.ts"}, want: []string{"web/app.ts"}},
+		{name: ".gitignore not consulted", files: []string{"ignored/i.go"}, want: []string{"ignored/i.go"}},
+		{name: "exclude", files: []string{"a.go", "gen/g.go"}, opts: Options{Exclude: []string{"gen/"}}, want: []string{"a.go"}},
+		{name: "include", files: []string{"a.go", "b/b.go"}, opts: Options{Include: []string{"b/**"}}, want: []string{"b/b.go"}},
+		{name: "languages", files: []string{"a.go", "web/app.ts"}, opts: Options{Languages: []string{"typescript"}}, want: []string{"web/app.ts"}},
+		{
+			name:  "missing file",
+			files: []string{"a.go", "deleted.go"},
+			want:  []string{"a.go"},
+			diags: []string{"deleted.go: stat: no such file or directory"},
+		},
+		{
+			name:  "outside the root",
+			files: []string{"../elsewhere.go"},
+			diags: []string{"../elsewhere.go: outside the scan root"},
+		},
+		{
+			name:  "directory",
+			files: []string{"odd.go"},
+			diags: []string{"odd.go: is a directory"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Files = tt.files
+			out := outline.New()
+			if err := ProcessFilesWithOptions(root, out, opts); err != nil {
+				t.Fatal(err)
+			}
+			var files []string
+			for path := range out.Files {
+				files = append(files, path)
+			}
+			sort.Strings(files)
+			if !slices.Equal(files, tt.want) {
+				t.Errorf("parsed %v, want %v", files, tt.want)
+			}
+			var diags []string
+			for _, d := range out.Diagnostics {
+				diags = append(diags, d.File+": "+d.Message)
+			}
+			if !slices.Equal(diags, tt.diags) {
+				t.Errorf("diagnostics %q, want %q", diags, tt.diags)
+			}
+		})
+	}
+}
+
+func TestProcessFilesListOrder(t *testing.T) {
+	root := writeTree(t, mergeTree)
+	files := []string{"a/a.go", "a/a2.go", "b/b.go", "b/broken.go", "web/api.ts", "web/types.ts"}
+	want := outlineJSON(t, root, Options{Files: files, Workers: 1})
+
+	for _, order := range [][]string{
+		{"web/types.ts", "b/broken.go", "a/a2.go", "web/api.ts", "b/b.go", "a/a.go"},
+		append(slices.Clone(files), files...),
+	} {
+		if got := outlineJSON(t, root, Options{Files: order, Workers: 8}); got != want {
+			t.Errorf("outline for %v differs from the sorted list", order)
+		}
+	}
+}
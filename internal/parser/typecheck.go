@@ -0,0 +1,407 @@
+package parser
+
+import (
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// Analysis modes accepted in Options.Analysis.
+const (
+	// AnalysisSyntax describes each file from its syntax tree alone; calls
+	// are recorded by bare name. It is the default.
+	AnalysisSyntax = "syntax"
+	// AnalysisTypes additionally type-checks Go packages, resolving calls
+	// to qualified names and type uses to their defining packages.
+	AnalysisTypes = "types"
+)
+
+// IsAnalysis reports whether name is a valid Options.Analysis value.
+func IsAnalysis(name string) bool {
+	return name == "" || name == AnalysisSyntax || name == AnalysisTypes
+}
+
+// typeChecker resolves the calls and type uses of Go functions in an outline
+// using go/types. Dependencies, including the standard library, are
+// type-checked from source.
+type typeChecker struct {
+	out     *outline.Outline
+	fset    *token.FileSet
+	absRoot string
+	imp     types.Importer
+
+	// funcs maps qualified function names declared in the outline to their
+	// FunctionInfo, for filling CalledBy.
+	funcs map[string]*outline.FunctionInfo
+	// resolved lists the functions that were type-checked, in the order
+	// they were resolved.
+	resolved []resolvedFunc
+	callers  map[string]bool
+}
+
+// resolvedFunc is the type-checked view of one function.
+type resolvedFunc struct {
+	caller     string   // "path:Func"
+	callees    []string // qualified callees
+	localTypes []string // TypeKeys of used types declared in the tree
+}
+
+// resolveGoTypes type-checks every Go package in out and replaces the
+// syntactic CallsTo, UsesTypes, FunctionCalls and TypeUsage data of its
+// functions with resolved names. Calls become "import/path.Func" or
+// "(import/path.Type).Method"; calls through function values and built-ins
+// are dropped. TypeUsage only lists types declared in the scanned tree, keyed
+// by the package that declares them. A package that fails to type-check keeps its
+// syntactic data and gets a warning diagnostic.
+func resolveGoTypes(out *outline.Outline, fset *token.FileSet) {
+	tc := &typeChecker{
+		out:     out,
+		fset:    fset,
+		absRoot: out.RootDir,
+		imp:     importer.ForCompiler(fset, "source", nil),
+		funcs:   make(map[string]*outline.FunctionInfo),
+		callers: make(map[string]bool),
+	}
+
+	// Group Go files by directory; each directory is one package.
+	dirs := make(map[string][]*outline.FileInfo)
+	for _, relPath := range sortedFilePaths(out) {
+		fi := out.Files[relPath]
+		if fi != nil && strings.HasSuffix(relPath, ".go") && fi.AbsPath != "" {
+			dir := filepath.Dir(fi.AbsPath)
+			dirs[dir] = append(dirs[dir], fi)
+		}
+	}
+	dirNames := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		dirNames = append(dirNames, dir)
+	}
+	sort.Strings(dirNames)
+	for _, dir := range dirNames {
+		tc.checkPackage(dir, dirs[dir])
+	}
+
+	tc.apply()
+}
+
+// checkPackage type-checks the package in dir and resolves the functions of
+// its outline files.
+func (tc *typeChecker) checkPackage(dir string, files []*outline.FileInfo) {
+	for _, fi := range files {
+		if fi.Degraded {
+			return // syntax errors are already reported
+		}
+	}
+
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		tc.fallback(files[0].PackageDir, token.Position{}, err.Error())
+		return
+	}
+	var astFiles []*ast.File
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		f, err := parser.ParseFile(tc.fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			tc.fallback(files[0].PackageDir, token.Position{}, err.Error())
+			return
+		}
+		astFiles = append(astFiles, f)
+	}
+
+	var firstErr *types.Error
+	conf := types.Config{
+		Importer:    tc.imp,
+		FakeImportC: len(bp.CgoFiles) > 0,
+		Error: func(err error) {
+			if te, ok := err.(types.Error); ok && firstErr == nil {
+				firstErr = &te
+			}
+		},
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	if _, err := conf.Check(importPath(files[0]), tc.fset, astFiles, info); err != nil {
+		if firstErr != nil {
+			tc.fallback(files[0].PackageDir, tc.fset.Position(firstErr.Pos), firstErr.Msg)
+		} else {
+			tc.fallback(files[0].PackageDir, token.Position{}, err.Error())
+		}
+		return
+	}
+
+	byPath := make(map[string]*outline.FileInfo, len(files))
+	for _, fi := range files {
+		byPath[fi.AbsPath] = fi
+	}
+	for _, f := range astFiles {
+		if fi := byPath[tc.fset.Position(f.Pos()).Filename]; fi != nil {
+			tc.resolveFile(f, fi, info)
+		}
+	}
+}
+
+// fallback records that a package keeps its syntactic analysis.
+func (tc *typeChecker) fallback(pkgDir string, pos token.Position, msg string) {
+	d := outline.Diagnostic{
+		File:     pkgDir,
+		Severity: outline.SeverityWarning,
+		Message:  "type-checking failed, using syntactic analysis: " + msg,
+	}
+	if pos.IsValid() {
+		d.File = toRepoRelativePath(tc.absRoot, pos.Filename)
+		d.Line, d.Column = pos.Line, pos.Column
+	}
+	tc.out.AddDiagnostic(d)
+}
+
+// resolveFile replaces the call and type data of the functions declared in
+// f, matching them to fi.Functions by name in declaration order.
+func (tc *typeChecker) resolveFile(f *ast.File, fi *outline.FileInfo, info *types.Info) {
+	byName := make(map[string][]int)
+	for i, fn := range fi.Functions {
+		byName[fn.Name] = append(byName[fn.Name], i)
+	}
+
+	for _, decl := range f.Decls {
+		d, ok := decl.(*ast.FuncDecl)
+		if !ok || d.Name.Name == "_" {
+			continue
+		}
+		name := d.Name.Name
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			name = "(" + receiverType(d.Recv.List[0].Type) + ") " + name
+		}
+		idx := byName[name]
+		if len(idx) == 0 {
+			continue
+		}
+		byName[name] = idx[1:]
+		funcInfo := &fi.Functions[idx[0]]
+		caller := fi.Path + ":" + funcInfo.Name
+
+		if obj, ok := info.Defs[d.Name].(*types.Func); ok {
+			tc.funcs[qualifiedFuncName(obj)] = funcInfo
+		}
+
+		rf := resolvedFunc{caller: caller, callees: resolveCalls(d, info)}
+		funcInfo.CallsTo = rf.callees
+		funcInfo.UsesTypes = []string{}
+		for _, obj := range usedTypeNames(d, info) {
+			funcInfo.UsesTypes = append(funcInfo.UsesTypes, obj.Pkg().Path()+"."+obj.Name())
+			if decl := tc.declaringFile(obj); decl != nil {
+				rf.localTypes = append(rf.localTypes, outline.TypeKey(outline.TypeScope(decl), obj.Name()))
+			}
+		}
+		tc.resolved = append(tc.resolved, rf)
+		tc.callers[caller] = true
+	}
+}
+
+// declaringFile returns the outline file declaring obj, or nil when obj is
+// declared outside the scanned tree.
+func (tc *typeChecker) declaringFile(obj types.Object) *outline.FileInfo {
+	filename := tc.fset.Position(obj.Pos()).Filename
+	if filename == "" {
+		return nil
+	}
+	return tc.out.Files[toRepoRelativePath(tc.absRoot, filename)]
+}
+
+// apply replaces the FunctionCalls and TypeUsage entries of resolved
+// functions with their type-checked ones and fills CalledBy for functions
+// declared in the tree.
+func (tc *typeChecker) apply() {
+	for typeName, users := range tc.out.TypeUsage {
+		kept := users[:0]
+		for _, user := range users {
+			if !tc.callers[user] {
+				kept = append(kept, user)
+			}
+		}
+		if len(kept) == 0 {
+			delete(tc.out.TypeUsage, typeName)
+		} else {
+			tc.out.TypeUsage[typeName] = kept
+		}
+	}
+
+	for _, rf := range tc.resolved {
+		delete(tc.out.FunctionCalls, rf.caller)
+		for _, callee := range rf.callees {
+			tc.out.AddFunctionCall(rf.caller, callee)
+			if target := tc.funcs[callee]; target != nil {
+				appendUniqueString(&target.CalledBy, rf.caller)
+			}
+		}
+		for _, typeName := range rf.localTypes {
+			tc.out.AddTypeUsage(typeName, rf.caller)
+		}
+	}
+}
+
+// resolveCalls returns the qualified names of the functions and methods d
+// calls, in order of first call. Conversions, built-ins and calls through
+// function values are skipped.
+func resolveCalls(d *ast.FuncDecl, info *types.Info) []string {
+	calls := []string{}
+	if d.Body == nil {
+		return calls
+	}
+	ast.Inspect(d.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if fn := calledFunc(call.Fun, info); fn != nil {
+			appendUniqueString(&calls, qualifiedFuncName(fn))
+		}
+		return true
+	})
+	return calls
+}
+
+// calledFunc returns the function or method expr refers to, or nil when
+// it is not a statically known function.
+func calledFunc(expr ast.Expr, info *types.Info) *types.Func {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		fn, _ := info.Uses[e].(*types.Func)
+		return fn
+	case *ast.SelectorExpr:
+		if sel := info.Selections[e]; sel != nil {
+			fn, _ := sel.Obj().(*types.Func)
+			return fn
+		}
+		fn, _ := info.Uses[e.Sel].(*types.Func) // qualified: pkg.Func
+		return fn
+	case *ast.IndexExpr: // explicit instantiation: F[T](...)
+		return calledFunc(e.X, info)
+	case *ast.IndexListExpr:
+		return calledFunc(e.X, info)
+	}
+	return nil
+}
+
+// qualifiedFuncName names fn as "import/path.Func" or, for methods,
+// "(import/path.Type).Method" regardless of pointer receivers.
+func qualifiedFuncName(fn *types.Func) string {
+	fn = fn.Origin()
+	if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+		return "(" + qualifiedTypeName(sig.Recv().Type()) + ")." + fn.Name()
+	}
+	if fn.Pkg() == nil {
+		return fn.Name()
+	}
+	return fn.Pkg().Path() + "." + fn.Name()
+}
+
+// qualifiedTypeName names a receiver type as "import/path.Type".
+func qualifiedTypeName(t types.Type) string {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := types.Unalias(t).(*types.Named); ok {
+		obj := named.Origin().Obj()
+		if obj.Pkg() == nil {
+			return obj.Name()
+		}
+		return obj.Pkg().Path() + "." + obj.Name()
+	}
+	return types.TypeString(t, nil)
+}
+
+// usedTypeNames returns the named types d uses in its signature, composite
+// literals and type assertions (the places the syntactic analysis looks),
+// in order of first use. Predeclared types such as error are skipped.
+func usedTypeNames(d *ast.FuncDecl, info *types.Info) []*types.TypeName {
+	var names []*types.TypeName
+	seen := make(map[*types.TypeName]bool)
+	var collect func(t types.Type)
+	collectTuple := func(tuple *types.Tuple) {
+		for i := range tuple.Len() {
+			collect(tuple.At(i).Type())
+		}
+	}
+	collect = func(t types.Type) {
+		switch t := types.Unalias(t).(type) {
+		case *types.Pointer:
+			collect(t.Elem())
+		case *types.Slice:
+			collect(t.Elem())
+		case *types.Array:
+			collect(t.Elem())
+		case *types.Chan:
+			collect(t.Elem())
+		case *types.Map:
+			collect(t.Key())
+			collect(t.Elem())
+		case *types.Signature:
+			collectTuple(t.Params())
+			collectTuple(t.Results())
+		case *types.Named:
+			obj := t.Origin().Obj()
+			if obj.Pkg() != nil && !seen[obj] {
+				seen[obj] = true
+				names = append(names, obj)
+			}
+			for i := range t.TypeArgs().Len() {
+				collect(t.TypeArgs().At(i))
+			}
+		}
+	}
+	collectExpr := func(expr ast.Expr) {
+		if expr == nil {
+			return
+		}
+		if tv, ok := info.Types[expr]; ok {
+			collect(tv.Type)
+		}
+	}
+
+	if fn, ok := info.Defs[d.Name].(*types.Func); ok {
+		sig := fn.Type().(*types.Signature)
+		collectTuple(sig.Params())
+		collectTuple(sig.Results())
+	}
+	if d.Body != nil {
+		ast.Inspect(d.Body, func(n ast.Node) bool {
+			switch e := n.(type) {
+			case *ast.CompositeLit:
+				collectExpr(e.Type)
+			case *ast.TypeAssertExpr:
+				collectExpr(e.Type)
+			}
+			return true
+		})
+	}
+	return names
+}
+
+// importPath returns the import path of the package containing fi, derived
+// from its module; without a module the repo-relative directory is used.
+func importPath(fi *outline.FileInfo) string {
+	if fi.ModulePath == "" {
+		return fi.PackageDir
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(fi.PackageDir, fi.ModuleDir), "/")
+	if fi.ModuleDir == "." {
+		rel = fi.PackageDir
+	}
+	if rel == "" || rel == "." {
+		return fi.ModulePath
+	}
+	return path.Join(fi.ModulePath, rel)
+}
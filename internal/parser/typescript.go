@@ -36,6 +36,11 @@ func parseTypeScriptContentRegex(content string, out *outline.Outline, fileInfo
 	constRegex := regexp.MustCompile(`^\s*const\s+(\w+)(?:\s*:\s*([^=]+))?\s*=`)
 	classRegex := regexp.MustCompile(`^\s*(?:export\s+)?class\s+(\w+)(?:\s+extends\s+\w+)?(?:\s+implements\s+[\w,\s]+)?\s*\{`)
 
+	// TypeScript types are scoped to the file declaring them.
+	ensureType := func(name string) *outline.TypeInfo {
+		return out.EnsureType(outline.TypeKey(outline.TypeScope(fileInfo), name))
+	}
+
 	var imports []string
 	var exports []string
 	var currentInterface string
@@ -55,28 +60,28 @@ func parseTypeScriptContentRegex(content string, out *outline.Outline, fileInfo
 		if matches := interfaceRegex.FindStringSubmatch(line); len(matches) > 1 {
 			// Save previous interface if any
 			if currentInterface != "" && len(interfaceProps) > 0 {
-				typeInfo := out.EnsureType(currentInterface)
+				typeInfo := ensureType(currentInterface)
 				typeInfo.Fields = append(typeInfo.Fields, interfaceProps...)
 			}
 
 			currentInterface = matches[1]
 			interfaceProps = []string{}
 			fileInfo.Types = append(fileInfo.Types, currentInterface)
-			out.EnsureType(currentInterface)
+			ensureType(currentInterface)
 		}
 
 		// Extract classes
 		if matches := classRegex.FindStringSubmatch(line); len(matches) > 1 {
 			// Save previous class if any
 			if currentClass != "" && len(classMethods) > 0 {
-				typeInfo := out.EnsureType(currentClass)
+				typeInfo := ensureType(currentClass)
 				typeInfo.Methods = append(typeInfo.Methods, classMethods...)
 			}
 
 			currentClass = matches[1]
 			classMethods = []string{}
 			fileInfo.Types = append(fileInfo.Types, currentClass)
-			out.EnsureType(currentClass)
+			ensureType(currentClass)
 		}
 
 		// Extract properties within interfaces
@@ -129,11 +134,11 @@ func parseTypeScriptContentRegex(content string, out *outline.Outline, fileInfo
 		// End of interface or class
 		if (currentInterface != "" || currentClass != "") && braceDepth == 0 && trimmedLine == "}" {
 			if currentInterface != "" && len(interfaceProps) > 0 {
-				typeInfo := out.EnsureType(currentInterface)
+				typeInfo := ensureType(currentInterface)
 				typeInfo.Fields = append(typeInfo.Fields, interfaceProps...)
 			}
 			if currentClass != "" && len(classMethods) > 0 {
-				typeInfo := out.EnsureType(currentClass)
+				typeInfo := ensureType(currentClass)
 				typeInfo.Methods = append(typeInfo.Methods, classMethods...)
 			}
 			currentInterface = ""
@@ -150,7 +155,7 @@ func parseTypeScriptContentRegex(content string, out *outline.Outline, fileInfo
 				typeValue = trimmed
 			}
 			fileInfo.Types = append(fileInfo.Types, typeName)
-			typeInfo := out.EnsureType(typeName)
+			typeInfo := ensureType(typeName)
 			typeInfo.Fields = append(typeInfo.Fields, "= "+typeValue)
 		}
 
@@ -229,11 +234,11 @@ func parseTypeScriptContentRegex(content string, out *outline.Outline, fileInfo
 
 	// Save final interface/class if any
 	if currentInterface != "" && len(interfaceProps) > 0 {
-		typeInfo := out.EnsureType(currentInterface)
+		typeInfo := ensureType(currentInterface)
 		typeInfo.Fields = append(typeInfo.Fields, interfaceProps...)
 	}
 	if currentClass != "" && len(classMethods) > 0 {
-		typeInfo := out.EnsureType(currentClass)
+		typeInfo := ensureType(currentClass)
 		typeInfo.Methods = append(typeInfo.Methods, classMethods...)
 	}
 
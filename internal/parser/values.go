@@ -0,0 +1,251 @@
+package parser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"unicode/utf8"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// maxValueLen caps the source text recorded for values that are not
+// constants, such as errors.New("...") calls.
+const maxValueLen = 80
+
+// maxConstShift caps shift counts in evaluated constants, matching the
+// 512-bit precision the gc compiler keeps for untyped constants.
+const maxConstShift = 512
+
+// addGoValues records the exported constants and variables of a const or
+// var declaration. Constants are evaluated where possible, so iota blocks
+// get their actual values; typed constants in a block that uses iota are
+// also listed as EnumValues of their named type.
+func addGoValues(d *ast.GenDecl, out *outline.Outline, fileInfo *outline.FileInfo) {
+	kind := outline.ValueVar
+	if d.Tok == token.CONST {
+		kind = outline.ValueConst
+	}
+	isEnum := d.Tok == token.CONST && usesIota(d)
+
+	consts := make(map[string]constant.Value) // earlier constants of the block
+	var lastType ast.Expr
+	var lastValues []ast.Expr
+	for iota, spec := range d.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		typ, values := vs.Type, vs.Values
+		// A constant spec without type and values repeats the previous one.
+		if d.Tok == token.CONST && typ == nil && len(values) == 0 {
+			typ, values = lastType, lastValues
+		}
+		lastType, lastValues = typ, values
+
+		doc := vs.Doc.Text()
+		if doc == "" && len(d.Specs) == 1 {
+			doc = d.Doc.Text()
+		}
+		for i, name := range vs.Names {
+			var expr ast.Expr
+			if i < len(values) {
+				expr = values[i]
+			}
+			var value string
+			if d.Tok == token.CONST {
+				if v := evalConst(expr, iota, consts); v != nil {
+					consts[name.Name] = v
+					value = constString(v)
+				} else if expr != nil && !hasBadExpr(expr) {
+					value = typeToString(expr)
+				}
+			} else if expr != nil && !hasBadExpr(expr) {
+				value = typeToString(expr)
+			}
+			if utf8.RuneCountInString(value) > maxValueLen {
+				value = ""
+			}
+
+			if !ast.IsExported(name.Name) {
+				continue
+			}
+			fileInfo.Values = append(fileInfo.Values, outline.ValueInfo{
+				Name:  name.Name,
+				Kind:  kind,
+				Type:  typeToString(typ),
+				Value: value,
+				Doc:   doc,
+			})
+			fileInfo.Vars = append(fileInfo.Vars, name.Name)
+			out.Vars = append(out.Vars, name.Name)
+
+			if id, ok := typ.(*ast.Ident); ok && isEnum && types.Universe.Lookup(id.Name) == nil {
+				ti := out.EnsureType(outline.TypeKey(outline.TypeScope(fileInfo), id.Name))
+				ti.EnumValues = append(ti.EnumValues, name.Name)
+			}
+		}
+	}
+}
+
+// usesIota reports whether any value in a const declaration refers to iota.
+func usesIota(d *ast.GenDecl) bool {
+	found := false
+	for _, spec := range d.Specs {
+		if vs, ok := spec.(*ast.ValueSpec); ok {
+			for _, v := range vs.Values {
+				ast.Inspect(v, func(n ast.Node) bool {
+					if id, ok := n.(*ast.Ident); ok && id.Name == "iota" {
+						found = true
+					}
+					return !found
+				})
+			}
+		}
+	}
+	return found
+}
+
+// evalConst evaluates a constant expression built from literals, iota,
+// earlier constants of the same declaration and conversions; nil when expr
+// uses anything else.
+func evalConst(expr ast.Expr, iota int, consts map[string]constant.Value) constant.Value {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		if v.Kind() == constant.Unknown {
+			return nil
+		}
+		return v
+	case *ast.Ident:
+		switch e.Name {
+		case "iota":
+			return constant.MakeInt64(int64(iota))
+		case "true", "false":
+			return constant.MakeBool(e.Name == "true")
+		}
+		return consts[e.Name]
+	case *ast.ParenExpr:
+		return evalConst(e.X, iota, consts)
+	case *ast.UnaryExpr:
+		x := evalConst(e.X, iota, consts)
+		if x == nil {
+			return nil
+		}
+		switch {
+		case (e.Op == token.ADD || e.Op == token.SUB) && isNumeric(x),
+			e.Op == token.XOR && x.Kind() == constant.Int,
+			e.Op == token.NOT && x.Kind() == constant.Bool:
+			return constant.UnaryOp(e.Op, x, 0)
+		}
+	case *ast.BinaryExpr:
+		x, y := evalConst(e.X, iota, consts), evalConst(e.Y, iota, consts)
+		if x == nil || y == nil {
+			return nil
+		}
+		if e.Op == token.SHL || e.Op == token.SHR {
+			if x.Kind() != constant.Int || y.Kind() != constant.Int {
+				return nil
+			}
+			s, ok := constant.Uint64Val(y)
+			if !ok || s > maxConstShift {
+				return nil
+			}
+			return constant.Shift(x, e.Op, uint(s))
+		}
+		if !binaryOpValid(e.Op, x, y) {
+			return nil
+		}
+		switch e.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(x, e.Op, y))
+		case token.QUO, token.REM:
+			if constant.Sign(y) == 0 {
+				return nil
+			}
+			if e.Op == token.QUO && x.Kind() == constant.Int && y.Kind() == constant.Int {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y) // integer division
+			}
+		}
+		return constant.BinaryOp(x, e.Op, y)
+	case *ast.CallExpr: // conversion such as int64(1) or float64(1)
+		if len(e.Args) == 1 {
+			return convertConst(e.Fun, evalConst(e.Args[0], iota, consts))
+		}
+	}
+	return nil
+}
+
+// convertConst converts x to the predeclared numeric type named by fun, or
+// returns nil. Conversions to other types, including named types such as
+// time.Duration whose underlying type is not known here, are not evaluated:
+// the converted value may be a float where x is an integer.
+func convertConst(fun ast.Expr, x constant.Value) constant.Value {
+	ident, ok := fun.(*ast.Ident)
+	if !ok || x == nil || !isNumeric(x) {
+		return nil
+	}
+	typeName, ok := types.Universe.Lookup(ident.Name).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	basic, ok := typeName.Type().(*types.Basic)
+	if !ok {
+		return nil
+	}
+	var v constant.Value
+	switch info := basic.Info(); {
+	case info&types.IsInteger != 0:
+		v = constant.ToInt(x)
+	case info&types.IsFloat != 0:
+		v = constant.ToFloat(x)
+	case info&types.IsComplex != 0:
+		v = constant.ToComplex(x)
+	}
+	if v == nil || v.Kind() == constant.Unknown {
+		return nil
+	}
+	return v
+}
+
+// constString renders a constant as Go source: quoted strings, exact
+// integers and decimal floats.
+func constString(v constant.Value) string {
+	if v.Kind() == constant.Float {
+		return v.String()
+	}
+	return v.ExactString()
+}
+
+// binaryOpValid reports whether go/constant can apply op to x and y.
+// go/parser accepts expressions that do not type-check, such as "a" - "b"
+// or 1.5 % 2, and go/constant panics on them.
+func binaryOpValid(op token.Token, x, y constant.Value) bool {
+	if x.Kind() != y.Kind() && !(isNumeric(x) && isNumeric(y)) {
+		return false
+	}
+	switch op {
+	case token.ADD:
+		return isNumeric(x) || x.Kind() == constant.String
+	case token.SUB, token.MUL, token.QUO:
+		return isNumeric(x)
+	case token.REM, token.AND, token.OR, token.XOR, token.AND_NOT:
+		return x.Kind() == constant.Int && y.Kind() == constant.Int
+	case token.LAND, token.LOR:
+		return x.Kind() == constant.Bool
+	case token.EQL, token.NEQ:
+		return true
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return x.Kind() != constant.Bool && x.Kind() != constant.Complex && y.Kind() != constant.Complex
+	}
+	return false
+}
+
+func isNumeric(v constant.Value) bool {
+	switch v.Kind() {
+	case constant.Int, constant.Float, constant.Complex:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// parseGoSource outlines src as the file a.go of the scan root's package.
+func parseGoSource(t *testing.T, src string) (*outline.Outline, *outline.FileInfo) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := outline.New()
+	fileInfo := out.AddFile("a.go", path)
+	fileInfo.PackageDir = "."
+	if err := parseGoFile(path, out, fileInfo, token.NewFileSet()); err != nil {
+		t.Fatal(err)
+	}
+	return out, fileInfo
+}
+
+func TestEvalConst(t *testing.T) {
+	consts := map[string]constant.Value{"KB": constant.MakeInt64(1024)}
+	tests := []struct {
+		expr string
+		iota int
+		want string // "" when the expression is not evaluated
+	}{
+		{"iota", 3, "3"},
+		{"1 << iota", 4, "16"},
+		{"KB * 4", 0, "4096"},
+		{"7 / 2", 0, "3"},
+		{"7 % 2", 0, "1"},
+		{"1.5 * 2", 0, "3"},
+		{"-(2 + 3)", 0, "-5"},
+		{"^0", 0, "-1"},
+		{`"a" + "b"`, 0, `"ab"`},
+		{"!true", 0, "false"},
+		{"1 < 2", 0, "true"},
+		{`"a" == "b"`, 0, "false"},
+		{"int64(2)", 0, "2"},
+		{"byte(1) << 3", 0, "8"},
+		{"int(7.0)", 0, "7"},
+		{"float64(1) / 3", 0, "0.333333"},
+		{"float32(KB) / 4096", 0, "0.25"},
+		{"complex128(1)", 0, "(1 + 0i)"},
+		{"int(7.5)", 0, ""},
+		{"int(true)", 0, ""},
+		{"Mode(2)", 0, ""},          // underlying type unknown
+		{"time.Duration(5)", 0, ""}, // underlying type unknown
+		{"Ratio(1) / 3", 0, ""},     // may be a float type
+		{"error(nil)", 0, ""},
+		{`len("abc")`, 0, ""},
+		{`string(65)`, 0, ""},
+		{"unsafe.Sizeof(x)", 0, ""},
+		{"Unknown + 1", 0, ""},
+		{"1 << 1000", 0, ""},
+
+		// Accepted by go/parser but invalid Go; go/constant panics on these.
+		{"!1", 0, ""},
+		{"-true", 0, ""},
+		{`^"a"`, 0, ""},
+		{"^1.5", 0, ""},
+		{"1.5 % 2", 0, ""},
+		{`"a" - "b"`, 0, ""},
+		{`"a" * 2`, 0, ""},
+		{"true + false", 0, ""},
+		{"true < false", 0, ""},
+		{"1 < true", 0, ""},
+		{`1 == "a"`, 0, ""},
+		{"1 & 1.5", 0, ""},
+		{"1 && 2", 0, ""},
+		{"1 / 0", 0, ""},
+		{"1.5 / 0", 0, ""},
+		{"1 % 0", 0, ""},
+		{"1 << 1.5", 0, ""},
+		{`"a" << 1`, 0, ""},
+		{"1 << -1", 0, ""},
+		{"1i < 2i", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if v := evalConst(expr, tt.iota, consts); v != nil {
+				got = constString(v)
+			}
+			if got != tt.want {
+				t.Errorf("evalConst(%s) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddGoValues(t *testing.T) {
+	out, fileInfo := parseGoSource(t, `package p
+
+import "errors"
+
+type Mode int
+
+// Modes.
+const (
+	// ModeFast runs quickly.
+	ModeFast Mode = iota
+	ModeSlow
+	modeHidden
+	ModeLast
+)
+
+const Bad = !1
+
+// ErrNotFound is returned when nothing matches.
+var ErrNotFound = errors.New("not found")
+
+func F() {
+	const Local = 5
+	var Exported = 3
+	_ = Local + Exported
+}
+`)
+
+	want := []outline.ValueInfo{
+		{Name: "ModeFast", Kind: outline.ValueConst, Type: "Mode", Value: "0", Doc: "ModeFast runs quickly.\n"},
+		{Name: "ModeSlow", Kind: outline.ValueConst, Type: "Mode", Value: "1"},
+		{Name: "ModeLast", Kind: outline.ValueConst, Type: "Mode", Value: "3"},
+		{Name: "Bad", Kind: outline.ValueConst, Value: "!1"},
+		{Name: "ErrNotFound", Kind: outline.ValueVar, Value: `errors.New("not found")`, Doc: "ErrNotFound is returned when nothing matches.\n"},
+	}
+	if !slices.Equal(fileInfo.Values, want) {
+		t.Errorf("Values =\n%+v\nwant\n%+v", fileInfo.Values, want)
+	}
+
+	wantVars := []string{"ModeFast", "ModeSlow", "ModeLast", "Bad", "ErrNotFound"}
+	if !slices.Equal(fileInfo.Vars, wantVars) {
+		t.Errorf("FileInfo.Vars = %v, want %v", fileInfo.Vars, wantVars)
+	}
+	if !slices.Equal(out.Vars, wantVars) {
+		t.Errorf("Outline.Vars = %v, want %v", out.Vars, wantVars)
+	}
+
+	mode := out.Types[outline.TypeKey(".", "Mode")]
+	if mode == nil {
+		t.Fatal("type Mode not recorded")
+	}
+	if wantEnum := []string{"ModeFast", "ModeSlow", "ModeLast"}; !slices.Equal(mode.EnumValues, wantEnum) {
+		t.Errorf("Mode.EnumValues = %v, want %v", mode.EnumValues, wantEnum)
+	}
+}
@@ -0,0 +1,122 @@
+package treasuremap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/impact"
+)
+
+// maxListedUsers caps the "used by" list of a contract.
+const maxListedUsers = 5
+
+// WriteJSON writes the map as indented JSON.
+func WriteJSON(w io.Writer, m *Map) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(m)
+}
+
+// WriteMarkdown writes the map as compact markdown with one mermaid diagram.
+func WriteMarkdown(w io.Writer, m *Map) error {
+	var sb strings.Builder
+	sb.WriteString("# Treasure Map\n\n")
+	fmt.Fprintf(&sb, "Seeds: %s\n\n", codeList(m.Seeds))
+	fmt.Fprintf(&sb, "Budget: %d/%d nodes, %d/%d edges, %d hops.", len(m.Nodes), m.Options.Nodes, len(m.Edges), m.Options.Edges, m.Options.Hops)
+	if m.DroppedNodes > 0 || m.DroppedEdges > 0 {
+		fmt.Fprintf(&sb, " Dropped %d lower-ranked nodes and %d edges.", m.DroppedNodes, m.DroppedEdges)
+	}
+	sb.WriteString("\n\n")
+
+	sb.WriteString("## Map\n\n")
+	writeMermaid(&sb, m)
+	sb.WriteString("\n")
+
+	sb.WriteString("## Nodes\n\n")
+	for _, n := range m.Nodes {
+		fmt.Fprintf(&sb, "- `%s`", n.Path)
+		var tags []string
+		if n.Kind == impact.KindPackage {
+			tags = append(tags, "package boundary")
+		}
+		if n.Seed {
+			tags = append(tags, "seed")
+		} else {
+			tags = append(tags, fmt.Sprintf("hop %d", n.Hop))
+		}
+		tags = append(tags, n.Risk+" risk")
+		fmt.Fprintf(&sb, " (%s)", strings.Join(tags, ", "))
+		if len(n.Reasons) > 0 {
+			fmt.Fprintf(&sb, ": %s", strings.Join(n.Reasons, "; "))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	if len(m.Contracts) > 0 || len(m.Routes) > 0 {
+		sb.WriteString("## Contracts to Watch\n\n")
+		for _, c := range m.Contracts {
+			fmt.Fprintf(&sb, "- `%s` in `%s` (keys: %s)", c.Name, c.File, strings.Join(c.Keys, ", "))
+			if len(c.UsedBy) > maxListedUsers {
+				fmt.Fprintf(&sb, ", used by: %s, ... +%d more", strings.Join(c.UsedBy[:maxListedUsers], ", "), len(c.UsedBy)-maxListedUsers)
+			} else if len(c.UsedBy) > 0 {
+				fmt.Fprintf(&sb, ", used by: %s", strings.Join(c.UsedBy, ", "))
+			}
+			sb.WriteString("\n")
+		}
+		for _, r := range m.Routes {
+			fmt.Fprintf(&sb, "- Routes in `%s`: %s\n", r.File, strings.Join(r.Routes, ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeMermaid(sb *strings.Builder, m *Map) {
+	sb.WriteString("```mermaid\n")
+	sb.WriteString("graph LR\n")
+
+	ids := make(map[string]string, len(m.Nodes))
+	for i, n := range m.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[nodeKey(n.Kind, n.Path)] = id
+
+		label := n.Path
+		shape := `["%s"]`
+		if n.Kind == impact.KindPackage {
+			label = "Boundary: " + n.Path
+			shape = `[["%s"]]`
+		}
+		class := n.Risk + "Risk"
+		if n.Seed {
+			class = "seed"
+		}
+		fmt.Fprintf(sb, "    %s"+shape+":::%s\n", id, strings.ReplaceAll(label, `"`, "'"), class)
+	}
+	for _, e := range m.Edges {
+		label := e.Kind
+		if len(e.Labels) > 0 {
+			label += ": " + strings.Join(e.Labels, ", ")
+		}
+		fmt.Fprintf(sb, "    %s -->|%s| %s\n", ids[nodeKey(e.FromKind, e.From)], label, ids[nodeKey(e.ToKind, e.To)])
+	}
+
+	sb.WriteString("    classDef seed fill:#cfe2ff,stroke:#0d6efd,stroke-width:3px\n")
+	sb.WriteString("    classDef highRisk fill:#ffcccc,stroke:#d32f2f,stroke-width:2px\n")
+	sb.WriteString("    classDef mediumRisk fill:#fff3cd,stroke:#ffc107,stroke-width:2px\n")
+	sb.WriteString("    classDef lowRisk fill:#d4edda,stroke:#28a745,stroke-width:2px\n")
+	sb.WriteString("```\n")
+}
+
+func codeList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = "`" + item + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
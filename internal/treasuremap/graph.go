@@ -0,0 +1,265 @@
+package treasuremap
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/impact"
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// graph answers neighbour queries over the outline's dependency maps.
+type graph struct {
+	out      *outline.Outline
+	typeDefs map[string][]string // type key -> files declaring it
+	fileDefs map[string][]string // file -> type keys it declares
+	fileUses map[string][]string // file -> type keys its functions use
+	names    func(string) string // type key -> display name
+}
+
+func newGraph(out *outline.Outline) *graph {
+	g := &graph{
+		out:      out,
+		typeDefs: make(map[string][]string),
+		fileDefs: make(map[string][]string),
+		fileUses: make(map[string][]string),
+		names:    out.TypeNames(),
+	}
+	for _, path := range sortedKeys(out.Files) {
+		fi := out.Files[path]
+		for _, name := range fi.Types {
+			key := outline.TypeKey(outline.TypeScope(fi), name)
+			if ti, ok := out.Types[key]; ok && ti != nil && ti.Name != "" {
+				g.typeDefs[key] = appendUnique(g.typeDefs[key], path)
+				g.fileDefs[path] = appendUnique(g.fileDefs[path], key)
+			}
+		}
+	}
+	for _, key := range sortedKeys(out.TypeUsage) {
+		for _, user := range out.TypeUsage[key] {
+			if file := usageFile(user); file != "" {
+				g.fileUses[file] = appendUnique(g.fileUses[file], key)
+			}
+		}
+	}
+	return g
+}
+
+// usageFile extracts the file from a TypeUsage entry ("path:Func").
+func usageFile(user string) string {
+	idx := strings.LastIndex(user, ":")
+	if idx < 0 {
+		return ""
+	}
+	return user[:idx]
+}
+
+func (g *graph) risk(key string) string {
+	kind, path := splitKey(key)
+	if kind == impact.KindPackage {
+		return g.out.CalculatePackageChangeImpact(path).RiskLevel
+	}
+	return g.out.CalculateChangeImpact(path).RiskLevel
+}
+
+// graphEdge is an Edge between node keys, as the traversal sees it.
+type graphEdge struct {
+	From, To string // node keys
+	Kind     string
+	Labels   []string
+}
+
+// edges returns every edge touching the node, in a stable order.
+func (g *graph) edges(key string) []graphEdge {
+	kind, path := splitKey(key)
+	if kind == impact.KindPackage {
+		return g.packageEdges(path)
+	}
+	return g.fileEdges(path)
+}
+
+func (g *graph) packageEdges(pkg string) []graphEdge {
+	var edges []graphEdge
+	self := nodeKey(impact.KindPackage, pkg)
+	for _, dep := range sortedCopy(g.out.PackageDeps[pkg]) {
+		edges = append(edges, graphEdge{From: self, To: nodeKey(impact.KindPackage, dep), Kind: EdgePackage})
+	}
+	for _, dep := range sortedCopy(g.out.PackageReverseDeps[pkg]) {
+		edges = append(edges, graphEdge{From: nodeKey(impact.KindPackage, dep), To: self, Kind: EdgePackage})
+	}
+	return edges
+}
+
+func (g *graph) fileEdges(path string) []graphEdge {
+	var edges []graphEdge
+	self := nodeKey(impact.KindFile, path)
+	fileNode := func(p string) string { return nodeKey(impact.KindFile, p) }
+
+	deps := sortedCopy(g.out.Dependencies[path])
+	dependents := sortedCopy(g.out.ReverseDeps[path])
+	for _, dep := range deps {
+		edges = append(edges, graphEdge{From: self, To: fileNode(dep), Kind: EdgeImports})
+	}
+	for _, dep := range dependents {
+		edges = append(edges, graphEdge{From: fileNode(dep), To: self, Kind: EdgeImports})
+	}
+
+	// Type edges, merged per file pair so several shared types make one edge.
+	typeEdges := make(map[string]*graphEdge)
+	addTypeEdge := func(from, to, typeKey string) {
+		kind := EdgeTypeUse
+		if ti := g.out.Types[typeKey]; ti != nil && len(ti.ContractKeys) > 0 {
+			kind = EdgeDTO
+		}
+		id := from + "|" + to + "|" + kind
+		e := typeEdges[id]
+		if e == nil {
+			e = &graphEdge{From: from, To: to, Kind: kind}
+			typeEdges[id] = e
+		}
+		e.Labels = appendUnique(e.Labels, g.names(typeKey))
+	}
+	for _, typeKey := range g.fileUses[path] {
+		for _, def := range g.typeDefs[typeKey] {
+			if def != path {
+				addTypeEdge(self, fileNode(def), typeKey)
+			}
+		}
+	}
+	for _, typeKey := range g.fileDefs[path] {
+		for _, user := range g.out.TypeUsage[typeKey] {
+			if file := usageFile(user); file != "" && file != path {
+				addTypeEdge(fileNode(file), self, typeKey)
+			}
+		}
+	}
+	for _, id := range sortedKeys(typeEdges) {
+		e := typeEdges[id]
+		sort.Strings(e.Labels)
+		edges = append(edges, *e)
+	}
+
+	// Package edges for Go files, skipped where a file edge already links
+	// the two packages; other packages are collapsed into boundary nodes.
+	fi := g.out.Files[path]
+	if fi == nil || fi.PackageName == "" {
+		return edges
+	}
+	pkgNode := func(p string) string { return nodeKey(impact.KindPackage, p) }
+	for _, dep := range sortedCopy(g.out.PackageDeps[fi.PackageDir]) {
+		if !g.anyInPackage(deps, dep) {
+			edges = append(edges, graphEdge{From: self, To: pkgNode(dep), Kind: EdgePackage})
+		}
+	}
+	for _, dep := range sortedCopy(g.out.PackageReverseDeps[fi.PackageDir]) {
+		if !g.anyInPackage(dependents, dep) {
+			edges = append(edges, graphEdge{From: pkgNode(dep), To: self, Kind: EdgePackage})
+		}
+	}
+	return edges
+}
+
+func (g *graph) anyInPackage(files []string, pkg string) bool {
+	for _, f := range files {
+		if fi := g.out.Files[f]; fi != nil && fi.PackageDir == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// selectEdges keeps the strongest edges among selected nodes: by kind
+// weight, then edges touching a seed, then by endpoints.
+func (g *graph) selectEdges(selected, seeds map[string]bool, budget int) ([]Edge, int) {
+	seen := make(map[string]bool)
+	var all []graphEdge
+	for _, key := range sortedKeys(selected) {
+		for _, e := range g.edges(key) {
+			if !selected[e.From] || !selected[e.To] {
+				continue
+			}
+			id := e.From + "|" + e.To + "|" + e.Kind
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			all = append(all, e)
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if edgeWeight[a.Kind] != edgeWeight[b.Kind] {
+			return edgeWeight[a.Kind] > edgeWeight[b.Kind]
+		}
+		aSeed, bSeed := seeds[a.From] || seeds[a.To], seeds[b.From] || seeds[b.To]
+		if aSeed != bSeed {
+			return aSeed
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	dropped := 0
+	if len(all) > budget {
+		all, dropped = all[:budget], len(all)-budget
+	}
+	edges := make([]Edge, len(all))
+	for i, e := range all {
+		fromKind, from := splitKey(e.From)
+		toKind, to := splitKey(e.To)
+		edges[i] = Edge{From: from, FromKind: fromKind, To: to, ToKind: toKind, Kind: e.Kind, Labels: e.Labels}
+	}
+	return edges, dropped
+}
+
+// contracts lists tagged structs declared in mapped files and their routes.
+func contracts(out *outline.Outline, nodes []Node) (types []Contract, routes []Routes) {
+	for _, n := range nodes {
+		fi := out.Files[n.Path]
+		if n.Kind != impact.KindFile || fi == nil {
+			continue
+		}
+		for _, name := range sortedCopy(fi.Types) {
+			key := outline.TypeKey(outline.TypeScope(fi), name)
+			ti := out.Types[key]
+			if ti == nil || len(ti.ContractKeys) == 0 {
+				continue
+			}
+			types = append(types, Contract{
+				Name:   name,
+				File:   n.Path,
+				Keys:   sortedCopy(ti.ContractKeys),
+				UsedBy: sortedCopy(out.TypeUsage[key]),
+			})
+		}
+		if len(fi.Routes) > 0 {
+			routes = append(routes, Routes{File: n.Path, Routes: sortedCopy(fi.Routes)})
+		}
+	}
+	return types, routes
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedCopy(items []string) []string {
+	result := append([]string(nil), items...)
+	sort.Strings(result)
+	return result
+}
+
+func appendUnique(items []string, item string) []string {
+	if slices.Contains(items, item) {
+		return items
+	}
+	return append(items, item)
+}
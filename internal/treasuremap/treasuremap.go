@@ -0,0 +1,262 @@
+package treasuremap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/impact"
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// Default budgets, matching docs/skill-treasure-map.md.
+const (
+	DefaultNodes = 20
+	DefaultEdges = 30
+	DefaultHops  = 2
+)
+
+// Edge kinds.
+const (
+	EdgeImports = "imports"   // file dependency (Dependencies / ReverseDeps)
+	EdgePackage = "package"   // Go package dependency (PackageDeps)
+	EdgeTypeUse = "uses_type" // From uses a type defined in To (TypeUsage)
+	EdgeDTO     = "dto"       // as EdgeTypeUse, for types with contract tags
+)
+
+// edgeWeight ranks edge kinds: contract-shaped edges matter most.
+var edgeWeight = map[string]int{
+	EdgeDTO:     4,
+	EdgeImports: 3,
+	EdgeTypeUse: 2,
+	EdgePackage: 1,
+}
+
+var riskBonus = map[string]int{"high": 3, "medium": 1}
+
+// Options bounds the map.
+type Options struct {
+	Nodes int `json:"nodes"` // maximum nodes, seeds included
+	Edges int `json:"edges"` // maximum edges
+	Hops  int `json:"hops"`  // maximum distance from a seed
+}
+
+// Node is a file or a collapsed package in the map.
+type Node struct {
+	Path    string   `json:"path"`
+	Kind    string   `json:"kind"` // impact.KindFile or impact.KindPackage
+	Seed    bool     `json:"seed"`
+	Hop     int      `json:"hop"`
+	Risk    string   `json:"riskLevel"`
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons,omitempty"` // how the node connects to the map
+}
+
+// Edge is a typed relationship between two nodes, each named by its Path
+// and Kind as in Node. Labels name the types behind uses_type and dto edges.
+type Edge struct {
+	From     string   `json:"from"`
+	FromKind string   `json:"fromKind"`
+	To       string   `json:"to"`
+	ToKind   string   `json:"toKind"`
+	Kind     string   `json:"kind"`
+	Labels   []string `json:"labels,omitempty"`
+}
+
+// Contract is a tagged struct or route surface defined in a map node.
+type Contract struct {
+	Name   string   `json:"name"`
+	File   string   `json:"file"`
+	Keys   []string `json:"keys"`
+	UsedBy []string `json:"usedBy"`
+}
+
+// Routes are the router-style routes registered in a mapped file.
+type Routes struct {
+	File   string   `json:"file"`
+	Routes []string `json:"routes"`
+}
+
+// Map is a bounded, seed-centred view of the outline.
+type Map struct {
+	Seeds        []string   `json:"seeds"`
+	Options      Options    `json:"options"`
+	Nodes        []Node     `json:"nodes"`
+	Edges        []Edge     `json:"edges"`
+	Contracts    []Contract `json:"contracts"`
+	Routes       []Routes   `json:"routes"`
+	DroppedNodes int        `json:"droppedNodes"`
+	DroppedEdges int        `json:"droppedEdges"`
+}
+
+// nodeKey identifies nodes internally; files and packages may share paths.
+func nodeKey(kind, path string) string {
+	return kind + ":" + path
+}
+
+func splitKey(key string) (kind, path string) {
+	kind, path, _ = strings.Cut(key, ":")
+	return kind, path
+}
+
+// Build traverses the outline from seeds (files or package directories)
+// and keeps the best-connected neighbours within the budgets.
+func Build(out *outline.Outline, seeds []string, opts Options) (*Map, error) {
+	if opts.Nodes <= 0 {
+		opts.Nodes = DefaultNodes
+	}
+	if opts.Edges <= 0 {
+		opts.Edges = DefaultEdges
+	}
+	if opts.Hops <= 0 {
+		opts.Hops = DefaultHops
+	}
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("at least one seed is required")
+	}
+
+	g := newGraph(out)
+	m := &Map{Options: opts}
+
+	type link struct {
+		parent string // key of a linked node one hop closer to a seed
+		reason string
+	}
+	type candidate struct {
+		hop   int
+		score int
+		links []link
+	}
+	cands := make(map[string]*candidate)
+	var seedKeys []string
+	for _, arg := range seeds {
+		path, kind, err := impact.ResolveTarget(out, arg)
+		if err != nil {
+			return nil, err
+		}
+		key := nodeKey(kind, path)
+		if _, dup := cands[key]; dup {
+			continue
+		}
+		cands[key] = &candidate{}
+		seedKeys = append(seedKeys, key)
+		m.Seeds = append(m.Seeds, path)
+	}
+	if len(seedKeys) > opts.Nodes {
+		return nil, fmt.Errorf("%d seeds exceed the node budget of %d", len(seedKeys), opts.Nodes)
+	}
+
+	// Breadth-first expansion; nodes first reached at the same hop
+	// accumulate score from every link.
+	frontier := append([]string(nil), seedKeys...)
+	for hop := 1; hop <= opts.Hops && len(frontier) > 0; hop++ {
+		sort.Strings(frontier)
+		var next []string
+		for _, from := range frontier {
+			for _, e := range g.edges(from) {
+				other := e.To
+				if other == from {
+					other = e.From
+				}
+				c, seen := cands[other]
+				if !seen {
+					c = &candidate{hop: hop, score: riskBonus[g.risk(other)]}
+					cands[other] = c
+					next = append(next, other)
+				}
+				if c.hop != hop {
+					continue
+				}
+				c.score += edgeWeight[e.Kind]
+				c.links = append(c.links, link{parent: from, reason: describe(e, other, from)})
+			}
+		}
+		frontier = next
+	}
+
+	// Seeds first, then by hop, score and path. A node is kept only when it
+	// links to a kept node one hop closer, so the map stays connected.
+	keys := make([]string, 0, len(cands))
+	for key := range cands {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := cands[keys[i]], cands[keys[j]]
+		if a.hop != b.hop {
+			return a.hop < b.hop
+		}
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		return keys[i] < keys[j]
+	})
+
+	selected := make(map[string]bool)
+	seedSet := make(map[string]bool)
+	for _, key := range seedKeys {
+		seedSet[key] = true
+	}
+	for _, key := range keys {
+		c := cands[key]
+		var reasons []string
+		for _, l := range c.links {
+			if selected[l.parent] {
+				reasons = append(reasons, l.reason)
+			}
+		}
+		if !seedSet[key] && (len(selected) >= opts.Nodes || len(reasons) == 0) {
+			m.DroppedNodes++
+			continue
+		}
+		selected[key] = true
+		kind, path := splitKey(key)
+		m.Nodes = append(m.Nodes, Node{
+			Path:    path,
+			Kind:    kind,
+			Seed:    seedSet[key],
+			Hop:     c.hop,
+			Risk:    g.risk(key),
+			Score:   c.score,
+			Reasons: uniqueSorted(reasons),
+		})
+	}
+
+	m.Edges, m.DroppedEdges = g.selectEdges(selected, seedSet, opts.Edges)
+	m.Contracts, m.Routes = contracts(out, m.Nodes)
+	return m, nil
+}
+
+// describe explains how node other is connected to the already mapped node.
+func describe(e graphEdge, other, mapped string) string {
+	_, target := splitKey(mapped)
+	outgoing := e.From == other
+	switch e.Kind {
+	case EdgeImports:
+		if outgoing {
+			return "imports " + target
+		}
+		return "imported by " + target
+	case EdgePackage:
+		if outgoing {
+			return "depends on " + target
+		}
+		return "dependency of " + target
+	default:
+		types := strings.Join(e.Labels, ", ")
+		if outgoing {
+			return "uses " + types + " from " + target
+		}
+		return "defines " + types + " used by " + target
+	}
+}
+
+func uniqueSorted(items []string) []string {
+	sort.Strings(items)
+	var result []string
+	for i, item := range items {
+		if i == 0 || item != items[i-1] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
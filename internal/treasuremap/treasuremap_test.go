@@ -0,0 +1,241 @@
+package treasuremap
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/impact"
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// testOutline builds a TypeScript app and two Go packages:
+//
+//	web/far.ts -> web/other.tsx -> web/page.tsx -> web/api.ts -> web/types.ts, web/http.ts
+//	web/api.ts uses the tagged type User from web/types.ts
+//	internal/y depends on internal/x
+func testOutline() *outline.Outline {
+	out := outline.New()
+	for _, path := range []string{"web/api.ts", "web/types.ts", "web/http.ts", "web/page.tsx", "web/other.tsx", "web/far.ts"} {
+		out.AddFile(path, "/repo/"+path)
+	}
+	for _, dep := range [][2]string{
+		{"web/api.ts", "web/types.ts"}, {"web/api.ts", "web/http.ts"}, {"web/page.tsx", "web/api.ts"},
+		{"web/other.tsx", "web/page.tsx"}, {"web/far.ts", "web/other.tsx"},
+	} {
+		out.AddDependency(dep[0], dep[1])
+	}
+	types := out.Files["web/types.ts"]
+	types.Types = []string{"User"}
+	user := out.EnsureType(outline.TypeKey("web/types.ts", "User"))
+	user.Name = "User"
+	user.ContractKeys = []string{"id", "name"}
+	out.AddTypeUsage(outline.TypeKey("web/types.ts", "User"), "web/api.ts:fetchUser")
+
+	for path, pkg := range map[string]string{"internal/x/x.go": "internal/x", "internal/y/y.go": "internal/y"} {
+		fi := out.AddFile(path, "/repo/"+path)
+		fi.PackageName = pkg[len("internal/"):]
+		fi.PackageDir = pkg
+		out.Packages[pkg] = &outline.PackageInfo{PackagePath: "example.com/m/" + pkg, Files: []string{path}}
+	}
+	out.AddPackageDependency("internal/y", "internal/x")
+	return out
+}
+
+func nodeNames(m *Map) []string {
+	var names []string
+	for _, n := range m.Nodes {
+		names = append(names, n.Path)
+	}
+	return names
+}
+
+func edgeNames(m *Map) []string {
+	var names []string
+	for _, e := range m.Edges {
+		names = append(names, e.From+" -"+e.Kind+"-> "+e.To)
+	}
+	return names
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name         string
+		seeds        []string
+		opts         Options
+		wantNodes    []string
+		wantEdges    []string
+		droppedNodes int
+		droppedEdges int
+	}{
+		{
+			name:      "ranked by hop, then score, then path",
+			seeds:     []string{"web/api.ts"},
+			wantNodes: []string{"web/api.ts", "web/types.ts", "web/http.ts", "web/page.tsx", "web/other.tsx"},
+			wantEdges: []string{
+				"web/api.ts -dto-> web/types.ts",
+				"web/api.ts -imports-> web/http.ts",
+				"web/api.ts -imports-> web/types.ts",
+				"web/page.tsx -imports-> web/api.ts",
+				"web/other.tsx -imports-> web/page.tsx",
+			},
+		},
+		{
+			name:      "hop limit",
+			seeds:     []string{"web/api.ts"},
+			opts:      Options{Hops: 1},
+			wantNodes: []string{"web/api.ts", "web/types.ts", "web/http.ts", "web/page.tsx"},
+			wantEdges: []string{
+				"web/api.ts -dto-> web/types.ts",
+				"web/api.ts -imports-> web/http.ts",
+				"web/api.ts -imports-> web/types.ts",
+				"web/page.tsx -imports-> web/api.ts",
+			},
+		},
+		{
+			// web/other.tsx only links through the dropped web/page.tsx, so
+			// it is dropped too rather than left disconnected.
+			name:         "node budget keeps the map connected",
+			seeds:        []string{"web/api.ts"},
+			opts:         Options{Nodes: 3},
+			wantNodes:    []string{"web/api.ts", "web/types.ts", "web/http.ts"},
+			wantEdges:    []string{"web/api.ts -dto-> web/types.ts", "web/api.ts -imports-> web/http.ts", "web/api.ts -imports-> web/types.ts"},
+			droppedNodes: 2,
+		},
+		{
+			name:         "edge budget keeps the strongest edges",
+			seeds:        []string{"web/api.ts"},
+			opts:         Options{Edges: 2},
+			wantNodes:    []string{"web/api.ts", "web/types.ts", "web/http.ts", "web/page.tsx", "web/other.tsx"},
+			wantEdges:    []string{"web/api.ts -dto-> web/types.ts", "web/api.ts -imports-> web/http.ts"},
+			droppedEdges: 3,
+		},
+		{
+			name:      "several seeds",
+			seeds:     []string{"web/other.tsx", "web/types.ts", "web/other.tsx"},
+			opts:      Options{Hops: 1},
+			wantNodes: []string{"web/other.tsx", "web/types.ts", "web/api.ts", "web/far.ts", "web/page.tsx"},
+			wantEdges: []string{
+				"web/api.ts -dto-> web/types.ts",
+				"web/api.ts -imports-> web/types.ts",
+				"web/far.ts -imports-> web/other.tsx",
+				"web/other.tsx -imports-> web/page.tsx",
+				"web/page.tsx -imports-> web/api.ts",
+			},
+		},
+		{
+			name:      "package seed",
+			seeds:     []string{"internal/x"},
+			wantNodes: []string{"internal/x", "internal/y"},
+			wantEdges: []string{"internal/y -package-> internal/x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Build(testOutline(), tt.seeds, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := nodeNames(m); !slices.Equal(got, tt.wantNodes) {
+				t.Errorf("nodes = %q, want %q", got, tt.wantNodes)
+			}
+			if got := edgeNames(m); !slices.Equal(got, tt.wantEdges) {
+				t.Errorf("edges = %q, want %q", got, tt.wantEdges)
+			}
+			if m.DroppedNodes != tt.droppedNodes || m.DroppedEdges != tt.droppedEdges {
+				t.Errorf("dropped %d nodes, %d edges, want %d, %d", m.DroppedNodes, m.DroppedEdges, tt.droppedNodes, tt.droppedEdges)
+			}
+			checkConnected(t, m)
+		})
+	}
+}
+
+// checkConnected verifies that seeds come first, every other node explains
+// its link to the map, and every edge joins two nodes of the map by path
+// and kind.
+func checkConnected(t *testing.T, m *Map) {
+	t.Helper()
+	kinds := make(map[string]string)
+	for i, n := range m.Nodes {
+		kinds[n.Path] = n.Kind
+		if n.Seed != (i < len(m.Seeds)) {
+			t.Errorf("node %d %s: seed = %v, want seeds first", i, n.Path, n.Seed)
+		}
+		if !n.Seed && len(n.Reasons) == 0 {
+			t.Errorf("node %s has no link to the map", n.Path)
+		}
+	}
+	for _, e := range m.Edges {
+		if kinds[e.From] != e.FromKind || kinds[e.To] != e.ToKind {
+			t.Errorf("edge %s (%s) -> %s (%s) does not join two map nodes", e.From, e.FromKind, e.To, e.ToKind)
+		}
+	}
+}
+
+func TestBuildReasons(t *testing.T) {
+	m, err := Build(testOutline(), []string{"web/api.ts"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"web/types.ts":  {"defines User used by web/api.ts", "imported by web/api.ts"},
+		"web/page.tsx":  {"imports web/api.ts"},
+		"web/other.tsx": {"imports web/page.tsx"},
+	}
+	for _, n := range m.Nodes {
+		if w, ok := want[n.Path]; ok && !slices.Equal(n.Reasons, w) {
+			t.Errorf("%s reasons = %q, want %q", n.Path, n.Reasons, w)
+		}
+	}
+	if len(m.Contracts) != 1 || m.Contracts[0].Name != "User" || !slices.Equal(m.Contracts[0].UsedBy, []string{"web/api.ts:fetchUser"}) {
+		t.Errorf("contracts = %+v, want User used by web/api.ts:fetchUser", m.Contracts)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		seeds   []string
+		opts    Options
+		wantErr string
+	}{
+		{"no seeds", nil, Options{}, "at least one seed"},
+		{"unknown seed", []string{"web/missing.ts"}, Options{}, "not a known file or package"},
+		{"seeds over the node budget", []string{"web/api.ts", "web/http.ts"}, Options{Nodes: 1}, "exceed the node budget"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Build(testOutline(), tt.seeds, tt.opts); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Build error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteJSONEdges(t *testing.T) {
+	m, err := Build(testOutline(), []string{"internal/x"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Edges []map[string]any `json:"edges"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"from": "internal/y", "fromKind": impact.KindPackage, "to": "internal/x", "toKind": impact.KindPackage, "kind": EdgePackage}
+	if len(doc.Edges) != 1 || len(doc.Edges[0]) != len(want) {
+		t.Fatalf("edges = %v, want [%v]", doc.Edges, want)
+	}
+	for key, value := range want {
+		if doc.Edges[0][key] != value {
+			t.Errorf("edge %s = %v, want %v", key, doc.Edges[0][key], value)
+		}
+	}
+}
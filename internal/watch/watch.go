@@ -0,0 +1,171 @@
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jasonwillschiu/codebrev/internal/gitignore"
+	"github.com/jasonwillschiu/codebrev/internal/parser"
+)
+
+// Default timings for Run.
+const (
+	DefaultInterval = time.Second
+	DefaultDebounce = 500 * time.Millisecond
+)
+
+// Options controls polling.
+type Options struct {
+	Interval time.Duration // how often to rescan the tree
+	Debounce time.Duration // how long the tree must be quiet before regenerating
+	// OnError receives regeneration errors; watching continues afterwards.
+	OnError func(error)
+	// ConfigFile is also watched, wherever it lives and even before it
+	// exists, so regenerate can reload it.
+	ConfigFile string
+	// Filter returns the parser filter for the next scan, so changes to
+	// files the parser skips are ignored. Nil watches every source file.
+	Filter func() *parser.Filter
+}
+
+// stamp is a cheap change fingerprint for one file.
+type stamp struct {
+	modTime int64
+	size    int64
+}
+
+// Snapshot maps absolute paths of watched files to their fingerprints.
+type Snapshot map[string]stamp
+
+// configFiles change the outline without being parsed themselves.
+var configFiles = map[string]bool{
+	".gitignore": true,
+	"go.mod":     true,
+	"go.work":    true,
+}
+
+// Take scans root with the same gitignore rules as parser.ProcessFiles and
+// records every source file filter keeps (all of them when filter is nil)
+// plus the files that shape module resolution. Unreadable entries are
+// skipped rather than failing the scan.
+func Take(root string, filter *parser.Filter) (Snapshot, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	// Rebuilt on every scan so edits to .gitignore take effect.
+	rules := gitignore.New(absRoot)
+
+	snap := make(Snapshot)
+	err = filepath.WalkDir(absRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != absRoot {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rules.ShouldIgnore(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if filter != nil && filter.SkipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !configFiles[d.Name()] && (!parser.IsSourceFile(path) || (filter != nil && filter.SkipFile(path))) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		snap[path] = stamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+		return nil
+	})
+	return snap, err
+}
+
+// Equal reports whether two snapshots describe the same tree.
+func (s Snapshot) Equal(other Snapshot) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for path, st := range s {
+		if other[path] != st {
+			return false
+		}
+	}
+	return true
+}
+
+// Run polls root until ctx is cancelled and calls regenerate once changes
+// have settled for the debounce window. A burst of saves (e.g. a formatter
+// touching many files) therefore triggers a single regeneration.
+func Run(ctx context.Context, root string, opts Options, regenerate func() error) error {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+
+	prev, err := take(root, opts)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	dirty := false
+	var lastChange time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			cur, err := take(root, opts)
+			if err != nil {
+				if opts.OnError != nil {
+					opts.OnError(err)
+				}
+				continue
+			}
+			if !cur.Equal(prev) {
+				prev = cur
+				dirty = true
+				lastChange = now
+			}
+			if dirty && now.Sub(lastChange) >= opts.Debounce {
+				dirty = false
+				if err := regenerate(); err != nil && opts.OnError != nil {
+					opts.OnError(err)
+				}
+			}
+		}
+	}
+}
+
+// take is Take with the filter and config file from opts.
+func take(root string, opts Options) (Snapshot, error) {
+	var filter *parser.Filter
+	if opts.Filter != nil {
+		filter = opts.Filter()
+	}
+	snap, err := Take(root, filter)
+	if err != nil || opts.ConfigFile == "" {
+		return snap, err
+	}
+	if info, err := os.Stat(opts.ConfigFile); err == nil {
+		snap[opts.ConfigFile] = stamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+	}
+	return snap, nil
+}
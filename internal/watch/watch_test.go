@@ -0,0 +1,76 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jasonwillschiu/codebrev/internal/parser"
+)
+
+func TestTakeFilter(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"go.mod", "a.go", "web/app.ts", "gen/g.go", "gen/go.mod", "README.md"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts *parser.Options // nil takes the snapshot without a filter
+		want []string
+	}{
+		{"no filter", nil, []string{"a.go", "gen/g.go", "gen/go.mod", "go.mod", "web/app.ts"}},
+		{"exclude dir", &parser.Options{Exclude: []string{"gen/"}}, []string{"a.go", "go.mod", "web/app.ts"}},
+		{"include", &parser.Options{Include: []string{"web/**"}}, []string{"gen/go.mod", "go.mod", "web/app.ts"}},
+		{"languages", &parser.Options{Languages: []string{"go"}}, []string{"a.go", "gen/g.go", "gen/go.mod", "go.mod"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filter *parser.Filter
+			if tt.opts != nil {
+				filter = parser.NewFilter(root, *tt.opts)
+			}
+			snap, err := Take(root, filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for path := range snap {
+				rel, _ := filepath.Rel(root, path)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Take = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTakeConfigFile(t *testing.T) {
+	root := t.TempDir()
+	configFile := filepath.Join(t.TempDir(), "codebrev.yaml")
+	opts := Options{ConfigFile: configFile}
+
+	before, err := take(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configFile, []byte("docs: full\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	after, err := take(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Equal(after) {
+		t.Error("creating the config file outside the root was not detected")
+	}
+}
@@ -0,0 +1,51 @@
+package writer
+
+import (
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/layering"
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+func writeArchitecture(writer *safeWriter, out *outline.Outline, rules []layering.Rule) {
+	violations := layering.Check(out, rules)
+
+	writer.Println("## Architecture Rules")
+	writer.Println("")
+	writer.Println("Package layering declared in the project config (checked by `codebrev lint-arch`):")
+	writer.Println("")
+	for _, rule := range rules {
+		var parts []string
+		if len(rule.Allow) > 0 {
+			parts = append(parts, "may only depend on "+codeJoin(rule.Allow))
+		}
+		if len(rule.Deny) > 0 {
+			parts = append(parts, "must not depend on "+codeJoin(rule.Deny))
+		}
+		writer.Printf("- `%s` %s\n", rule.From, strings.Join(parts, "; "))
+	}
+	writer.Println("")
+
+	if len(violations) == 0 {
+		writer.Println("No violations.")
+		writer.Println("")
+		return
+	}
+	writer.Printf("### Violations (%d)\n", len(violations))
+	for _, v := range violations {
+		writer.Printf("- `%s` -> `%s`: %s (imports: %d, calls: %d, type uses: %d)\n",
+			v.From, v.To, v.Reason, v.Stats.Imports, v.Stats.Calls, v.Stats.TypeUses)
+		if len(v.Files) > 0 {
+			writer.Printf("  - from: %s\n", strings.Join(v.Files, ", "))
+		}
+	}
+	writer.Println("")
+}
+
+func codeJoin(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = "`" + item + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
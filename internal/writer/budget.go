@@ -0,0 +1,140 @@
+package writer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Block priorities under a token budget; lower values are kept first.
+const (
+	priorityHeader     = iota // always kept
+	prioritySummary           // diagnostics, contracts, architecture, cycles, change impact, public API
+	priorityHighRisk          // file blocks of high-risk files
+	priorityMediumRisk        // file blocks of medium-risk files
+	priorityContext           // guidelines, reverse dependencies
+	priorityDiagram           // the mermaid dependency map
+	priorityLowRisk           // all other file blocks
+)
+
+// maxListedFiles caps how many omitted files the truncation notice names.
+const maxListedFiles = 20
+
+// block is one independently droppable piece of the markdown outline.
+type block struct {
+	name     string // section name, or file path for file blocks
+	isFile   bool
+	risk     string // file blocks only
+	priority int
+	text     string
+}
+
+// EstimateTokens approximates the token count of s at four bytes per
+// token, which is close for code-heavy English text.
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+func filePriority(risk string) int {
+	switch risk {
+	case "high":
+		return priorityHighRisk
+	case "medium":
+		return priorityMediumRisk
+	}
+	return priorityLowRisk
+}
+
+// fitBudget keeps the most important blocks whose estimated size fits in
+// maxTokens, in document order, and adds a notice after the header listing
+// what was dropped. Blocks are considered by priority, then by document
+// order; a block that does not fit is skipped and smaller ones after it may
+// still be kept. The result depends only on the blocks, not on map order.
+func fitBudget(blocks []block, maxTokens int) []block {
+	order := make([]int, len(blocks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return blocks[order[a]].priority < blocks[order[b]].priority
+	})
+
+	keep := make([]bool, len(blocks))
+	used := 0
+	for _, i := range order {
+		cost := EstimateTokens(blocks[i].text)
+		if blocks[i].priority == priorityHeader || used+cost <= maxTokens {
+			keep[i] = true
+			used += cost
+		}
+	}
+
+	// Make room for the notice itself, dropping the least important blocks.
+	notice := omissionNotice(blocks, keep, maxTokens)
+	for notice != "" && used+EstimateTokens(notice) > maxTokens {
+		dropped := false
+		for j := len(order) - 1; j >= 0; j-- {
+			i := order[j]
+			if keep[i] && blocks[i].priority != priorityHeader {
+				keep[i] = false
+				used -= EstimateTokens(blocks[i].text)
+				dropped = true
+				break
+			}
+		}
+		if !dropped {
+			break
+		}
+		notice = omissionNotice(blocks, keep, maxTokens)
+	}
+
+	var result []block
+	for i, b := range blocks {
+		if !keep[i] {
+			continue
+		}
+		result = append(result, b)
+		if b.priority == priorityHeader && notice != "" {
+			result = append(result, block{name: "omitted", text: notice})
+		}
+	}
+	return result
+}
+
+// omissionNotice describes the dropped blocks, or returns "" if none were.
+func omissionNotice(blocks []block, keep []bool, maxTokens int) string {
+	var sections, files []string
+	riskCounts := map[string]int{}
+	for i, b := range blocks {
+		if keep[i] {
+			continue
+		}
+		if b.isFile {
+			files = append(files, b.name)
+			riskCounts[b.risk]++
+		} else {
+			sections = append(sections, b.name)
+		}
+	}
+	if len(sections) == 0 && len(files) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Omitted Content (token budget)\n\n")
+	fmt.Fprintf(&sb, "This outline was trimmed to fit about %d tokens (estimated at 4 bytes per token). Omitted:\n", maxTokens)
+	if len(sections) > 0 {
+		fmt.Fprintf(&sb, "- Sections: %s\n", strings.Join(sections, ", "))
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(&sb, "- %d file sections (high risk: %d, medium risk: %d, low risk: %d): ",
+			len(files), riskCounts["high"], riskCounts["medium"], riskCounts["low"])
+		if len(files) > maxListedFiles {
+			fmt.Fprintf(&sb, "%s, ... +%d more\n", strings.Join(files[:maxListedFiles], ", "), len(files)-maxListedFiles)
+		} else {
+			fmt.Fprintf(&sb, "%s\n", strings.Join(files, ", "))
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
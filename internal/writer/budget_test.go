@@ -0,0 +1,152 @@
+package writer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sized returns a block whose text is estimated at tokens tokens.
+func sized(name string, priority, tokens int) block {
+	b := block{name: name, priority: priority, text: strings.Repeat("x", 4*tokens)}
+	switch priority {
+	case priorityHighRisk:
+		b.isFile, b.risk = true, "high"
+	case priorityMediumRisk:
+		b.isFile, b.risk = true, "medium"
+	case priorityLowRisk:
+		b.isFile, b.risk = true, "low"
+	}
+	return b
+}
+
+func TestFitBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		blocks    []block
+		maxTokens int
+		want      []string // kept block names, in order
+		omitted   []string // names the notice must list
+	}{
+		{
+			name:      "everything fits",
+			blocks:    []block{sized("header", priorityHeader, 10), sized("Public API", prioritySummary, 50), sized("a.go", priorityLowRisk, 50)},
+			maxTokens: 110,
+			want:      []string{"header", "Public API", "a.go"},
+		},
+		{
+			name: "risk order",
+			blocks: []block{
+				sized("header", priorityHeader, 10),
+				sized("low.go", priorityLowRisk, 100),
+				sized("Public API", prioritySummary, 50),
+				sized("medium.go", priorityMediumRisk, 100),
+				sized("high.go", priorityHighRisk, 100),
+			},
+			maxTokens: 330,
+			want:      []string{"header", "omitted", "Public API", "medium.go", "high.go"},
+			omitted:   []string{"low.go", "low risk: 1"},
+		},
+		{
+			name: "diagram before low-risk files",
+			blocks: []block{
+				sized("header", priorityHeader, 10),
+				sized("Dependency Map", priorityDiagram, 100),
+				sized("Guidelines", priorityContext, 100),
+				sized("a.go", priorityLowRisk, 100),
+			},
+			maxTokens: 300,
+			want:      []string{"header", "omitted", "Dependency Map", "Guidelines"},
+			omitted:   []string{"a.go"},
+		},
+		{
+			name: "smaller block after a skipped one",
+			blocks: []block{
+				sized("header", priorityHeader, 10),
+				sized("a.go", priorityLowRisk, 200),
+				sized("b.go", priorityLowRisk, 50),
+			},
+			maxTokens: 150,
+			want:      []string{"header", "omitted", "b.go"},
+			omitted:   []string{"a.go"},
+		},
+		{
+			name: "notice displaces the least important block",
+			blocks: []block{
+				sized("header", priorityHeader, 10),
+				sized("high.go", priorityHighRisk, 100),
+				sized("medium.go", priorityMediumRisk, 80),
+				sized("low.go", priorityLowRisk, 200),
+			},
+			maxTokens: 200,
+			want:      []string{"header", "omitted", "high.go"},
+			omitted:   []string{"medium.go", "low.go"},
+		},
+		{
+			name:      "header over budget",
+			blocks:    []block{sized("header", priorityHeader, 500), sized("a.go", priorityLowRisk, 10)},
+			maxTokens: 100,
+			want:      []string{"header", "omitted"},
+			omitted:   []string{"a.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fitBudget(tt.blocks, tt.maxTokens)
+			var names []string
+			used := 0
+			notice := ""
+			for _, b := range result {
+				names = append(names, b.name)
+				used += EstimateTokens(b.text)
+				if b.name == "omitted" {
+					notice = b.text
+				}
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Fatalf("kept %v, want %v", names, tt.want)
+			}
+			// Only a header larger than the budget may overrun it.
+			if header := EstimateTokens(tt.blocks[0].text); used > tt.maxTokens && header <= tt.maxTokens {
+				t.Errorf("kept %d tokens, over the budget of %d", used, tt.maxTokens)
+			}
+			for _, s := range tt.omitted {
+				if !strings.Contains(notice, s) {
+					t.Errorf("notice does not mention %q:\n%s", s, notice)
+				}
+			}
+		})
+	}
+}
+
+func TestFitBudgetListsAtMostMaxListedFiles(t *testing.T) {
+	blocks := []block{sized("header", priorityHeader, 10)}
+	for i := range maxListedFiles + 5 {
+		blocks = append(blocks, sized(strings.Repeat("f", i+1)+".go", priorityLowRisk, 1000))
+	}
+	result := fitBudget(blocks, 200)
+	if len(result) != 2 || result[1].name != "omitted" {
+		t.Fatalf("fitBudget kept %d blocks, want the header and the notice", len(result))
+	}
+	if !strings.Contains(result[1].text, "... +5 more") {
+		t.Errorf("notice does not cap the file list:\n%s", result[1].text)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{strings.Repeat("x", 400), 100},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.s); got != tt.want {
+			t.Errorf("EstimateTokens(%d bytes) = %d, want %d", len(tt.s), got, tt.want)
+		}
+	}
+}
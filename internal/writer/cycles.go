@@ -0,0 +1,35 @@
+package writer
+
+import (
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+func writeCycles(writer *safeWriter, fileCycles, packageCycles []outline.Cycle) {
+	writer.Println("## Cycles")
+	writer.Println("")
+	writer.Println("Every member of a cycle depends on every other, so a change to one can break all of them.")
+	writer.Println("Break a cycle by removing one of its edges, e.g. by moving shared code into a module both sides import.")
+	writer.Println("")
+	writeCycleList(writer, "Package cycles", packageCycles)
+	writeCycleList(writer, "File cycles", fileCycles)
+}
+
+func writeCycleList(writer *safeWriter, title string, cycles []outline.Cycle) {
+	if len(cycles) == 0 {
+		return
+	}
+	writer.Printf("### %s (%d)\n", title, len(cycles))
+	for i, c := range cycles {
+		writer.Printf("%d. %s\n", i+1, strings.Join(c.Nodes, ", "))
+		for _, e := range c.Edges {
+			writer.Printf("   - %s -> %s", e.From, e.To)
+			if e.Stats != nil {
+				writer.Printf(" (imports: %d, calls: %d, type uses: %d)", e.Stats.Imports, e.Stats.Calls, e.Stats.TypeUses)
+			}
+			writer.Println("")
+		}
+	}
+	writer.Println("")
+}
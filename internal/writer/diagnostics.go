@@ -0,0 +1,17 @@
+package writer
+
+import (
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+func writeDiagnostics(writer *safeWriter, out *outline.Outline) {
+	writer.Println("## Diagnostics")
+	writer.Println("")
+	writer.Printf("%d problem(s) found while scanning. Files that could not be read are missing from this outline;\n", len(out.Diagnostics))
+	writer.Println("files with syntax errors are outlined from a partial parse and marked as degraded.")
+	writer.Println("")
+	for _, d := range out.Diagnostics {
+		writer.Printf("- %s\n", d)
+	}
+	writer.Println("")
+}
@@ -0,0 +1,63 @@
+package writer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// headerPrefix starts the provenance comment on the first line of the
+// markdown outline.
+const headerPrefix = "<!-- codebrev "
+
+// Header is the provenance comment on the first line of the markdown
+// outline. It lets `codebrev check` tell a stale or hand-edited outline from
+// a current one.
+type Header struct {
+	Version     string // codebrev version that wrote the outline
+	Fingerprint string // Fingerprint of everything after the header line
+}
+
+// Fingerprint returns the content hash recorded in the header for body.
+func Fingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (h Header) String() string {
+	return fmt.Sprintf("%sversion=%s fingerprint=%s -->\n", headerPrefix, h.Version, h.Fingerprint)
+}
+
+// withHeader prefixes a document body with its header. An empty version is
+// written as "dev".
+func withHeader(body, version string) string {
+	if version == "" {
+		version = "dev"
+	}
+	return Header{Version: version, Fingerprint: Fingerprint([]byte(body))}.String() + body
+}
+
+// SplitHeader separates the header line from the body of a markdown
+// outline. ok is false, and body is all of content, when the first line is
+// not a codebrev header (for example, an outline written by an older
+// version).
+func SplitHeader(content []byte) (h Header, body []byte, ok bool) {
+	line, rest, found := bytes.Cut(content, []byte("\n"))
+	text := strings.TrimSpace(string(line))
+	if !found || !strings.HasPrefix(text, headerPrefix) || !strings.HasSuffix(text, "-->") {
+		return Header{}, content, false
+	}
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(text, headerPrefix), "-->"))
+	for _, field := range fields {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "version":
+			h.Version = value
+		case "fingerprint":
+			h.Fingerprint = value
+		}
+	}
+	return h, rest, true
+}
@@ -0,0 +1,60 @@
+package writer
+
+import (
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// implementedInterfaces returns the keys of the interfaces in out that have
+// at least one implementer, sorted with outline.SortTypeKeys.
+func implementedInterfaces(out *outline.Outline) []string {
+	var ifaces []string
+	for key, ti := range out.Types {
+		if ti != nil && ti.Kind == outline.KindInterface && len(out.Implementers(key)) > 0 {
+			ifaces = append(ifaces, key)
+		}
+	}
+	outline.SortTypeKeys(ifaces)
+	return ifaces
+}
+
+func writeInterfaces(writer *safeWriter, out *outline.Outline, ifaces []string) {
+	declaredIn := make(map[string][]string)
+	for _, path := range sortedKeys(out.Files) {
+		fi := out.Files[path]
+		for _, name := range fi.Types {
+			key := outline.TypeKey(outline.TypeScope(fi), name)
+			declaredIn[key] = append(declaredIn[key], path)
+		}
+	}
+	names := out.TypeNames()
+	located := func(key string) string {
+		if files := declaredIn[key]; len(files) > 0 {
+			return names(key) + " (" + strings.Join(files, ", ") + ")"
+		}
+		return names(key)
+	}
+
+	writer.Println("## Interfaces and Implementations")
+	writer.Println("")
+	writer.Println("Types whose methods, including those promoted from embedded types, satisfy an interface declared in this repo.")
+	writer.Println("Changing an interface method means changing every implementer; Go does not declare them, so grep will not find them.")
+	writer.Println("")
+	for _, iface := range ifaces {
+		writer.Printf("### %s\n", located(iface))
+		ti := out.Types[iface]
+		if len(ti.Methods) > 0 {
+			writer.Printf("- Methods: %s\n", strings.Join(ti.Methods, ", "))
+		}
+		if len(ti.EmbeddedTypes) > 0 {
+			writer.Printf("- Embeds: %s\n", typeNameList(ti.EmbeddedTypes, names))
+		}
+		implementers := out.Implementers(iface)
+		for i, key := range implementers {
+			implementers[i] = located(key)
+		}
+		writer.Printf("- Implemented by: %s\n", strings.Join(implementers, ", "))
+		writer.Println("")
+	}
+}
@@ -0,0 +1,43 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// WriteOutlineJSON writes the outline as a versioned JSON document.
+// Map keys are emitted in sorted order, so output is stable between runs.
+func WriteOutlineJSON(w io.Writer, out *outline.Outline, generator string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(outline.NewDocument(out, generator))
+}
+
+// WriteOutlineJSONToFileWithPath writes the JSON document to a specified file path
+func WriteOutlineJSONToFileWithPath(out *outline.Outline, filePath, generator string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(file)
+	if err := WriteOutlineJSON(bw, out, generator); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Code outline written to %s\n", filePath)
+	return nil
+}
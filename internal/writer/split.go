@@ -0,0 +1,253 @@
+package writer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jasonwillschiu/codebrev/internal/mermaid"
+	"github.com/jasonwillschiu/codebrev/internal/outline"
+)
+
+// SplitIndexName is the index file WriteSplit writes at the top of its
+// directory; package files go under SplitPackagesDir.
+const (
+	SplitIndexName   = "index.md"
+	SplitPackagesDir = "packages"
+)
+
+// rootPackageFile names the file of the scan root's own package. Go ignores
+// directories starting with "_", so it cannot clash with a real package.
+const rootPackageFile = "_root.md"
+
+// PackageFile returns the path of a package's file relative to the split
+// output directory, mirroring the package directory.
+func PackageFile(pkg string) string {
+	if pkg == "" || pkg == "." {
+		return path.Join(SplitPackagesDir, rootPackageFile)
+	}
+	return path.Join(SplitPackagesDir, pkg+".md")
+}
+
+// relLink returns the relative link from the split file from to the split
+// file to; both are paths relative to the output directory.
+func relLink(from, to string) string {
+	rel, err := filepath.Rel(path.Dir(from), to)
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// WriteSplit writes the outline to dir as an index (project-wide sections
+// and a package table) plus one file per package in out.Packages, linked to
+// each other with relative links. Package files left in dir by earlier runs
+// for packages that no longer exist are removed. opts.MaxTokens is ignored.
+func WriteSplit(out *outline.Outline, dir string, opts Options) error {
+	pkgs := sortedKeys(out.Packages)
+	docs := map[string]string{SplitIndexName: render(func(w *safeWriter) { writeSplitIndex(w, out, pkgs, opts) })}
+	for _, pkg := range pkgs {
+		docs[PackageFile(pkg)] = render(func(w *safeWriter) { writePackageFile(w, out, pkg, opts) })
+	}
+
+	for _, name := range sortedKeys(docs) {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, []byte(withHeader(docs[name], opts.Version)), 0o644); err != nil {
+			return err
+		}
+	}
+	if err := removeStalePackageFiles(dir, docs); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Code outline written to %s (%d package files)\n", filepath.Join(dir, SplitIndexName), len(pkgs))
+	return nil
+}
+
+func writeSplitIndex(w *safeWriter, out *outline.Outline, pkgs []string, opts Options) {
+	w.Println("# Code Structure Outline")
+	w.Println("")
+	w.Println("This index summarizes the project for LLM context. Functions and types are in one file per package; open only the packages you need.")
+	w.Println("")
+
+	if opts.wants(SectionDiagnostics) && len(out.Diagnostics) > 0 {
+		writeDiagnostics(w, out)
+	}
+
+	w.Println("## Packages")
+	w.Println("")
+	w.Println("| Package | Files | Risk | Depends on |")
+	w.Println("|---|---|---|---|")
+	for _, pkg := range pkgs {
+		impact := out.CalculatePackageChangeImpact(pkg)
+		deps := "none"
+		if len(out.PackageDeps[pkg]) > 0 {
+			sorted := append([]string(nil), out.PackageDeps[pkg]...)
+			sort.Strings(sorted)
+			deps = strings.Join(sorted, ", ")
+		}
+		w.Printf("| [%s](%s) | %d | %s | %s |\n", packageLabel(pkg), PackageFile(pkg),
+			len(out.Packages[pkg].Files), impact.RiskLevel, deps)
+	}
+	w.Println("")
+
+	if opts.wants(SectionDependencyMap) {
+		w.Println("## Dependency Map (LLM + Human Context)")
+		w.Println("")
+		w.Println("Note: External imports are intentionally omitted here; check go.mod (or module go.mod files in go.work workspaces) for dependencies.")
+		w.Println("")
+		w.Print(mermaid.GenerateUnifiedDependencyMap(out))
+		w.Println("")
+	}
+	if opts.wants(SectionContracts) {
+		writeContracts(w, out)
+	}
+	if ifaces := implementedInterfaces(out); opts.wants(SectionInterfaces) && len(ifaces) > 0 {
+		writeInterfaces(w, out, ifaces)
+	}
+	if opts.wants(SectionArchitecture) && len(opts.ArchRules) > 0 {
+		writeArchitecture(w, out, opts.ArchRules)
+	}
+	if fileCycles, packageCycles := out.FileCycles(), out.PackageCycles(); opts.wants(SectionCycles) && (len(fileCycles) > 0 || len(packageCycles) > 0) {
+		writeCycles(w, fileCycles, packageCycles)
+	}
+	if opts.wants(SectionGuidelines) {
+		writeAIAgentGuidance(w, out)
+	}
+	if opts.wants(SectionChangeImpact) {
+		writeChangeImpactAnalysis(w, out)
+	}
+}
+
+func writePackageFile(w *safeWriter, out *outline.Outline, pkg string, opts Options) {
+	self := PackageFile(pkg)
+	files := append([]string(nil), out.Packages[pkg].Files...)
+	sort.Strings(files)
+
+	w.Printf("# Package %s\n", packageLabel(pkg))
+	w.Println("")
+	w.Printf("[Index](%s)\n", relLink(self, SplitIndexName))
+	w.Println("")
+
+	impact := out.CalculatePackageChangeImpact(pkg)
+	w.Printf("- Risk: %s (%d direct + %d indirect dependent packages)\n",
+		impact.RiskLevel, len(impact.DirectDependents), len(impact.IndirectDependents))
+	w.Printf("- Depends on: %s\n", packageLinks(out, self, out.PackageDeps[pkg]))
+	w.Printf("- Used by: %s\n", packageLinks(out, self, out.PackageReverseDeps[pkg]))
+	w.Println("")
+
+	if opts.wants(SectionPublicAPI) {
+		var apiFiles []string
+		for _, f := range files {
+			if len(out.PublicAPIs[f]) > 0 {
+				apiFiles = append(apiFiles, f)
+			}
+		}
+		if len(apiFiles) > 0 {
+			w.Println("## Public API Surface")
+			w.Println("")
+			writePublicAPIs(w, out, apiFiles)
+		}
+	}
+	if opts.wants(SectionReverseDeps) {
+		var used []string
+		for _, f := range files {
+			if len(out.ReverseDeps[f]) > 0 {
+				used = append(used, f)
+			}
+		}
+		if len(used) > 0 {
+			w.Println("## Reverse Dependencies")
+			w.Println("")
+			writeReverseDepsOf(w, out, used)
+		}
+	}
+	if opts.wants(SectionFiles) {
+		for _, f := range files {
+			if _, ok := out.Files[f]; ok {
+				writeFileSection(w, out, f, opts.Docs)
+			}
+		}
+	}
+}
+
+func packageLabel(pkg string) string {
+	if pkg == "" || pkg == "." {
+		return "(root)"
+	}
+	return pkg
+}
+
+// packageLinks formats pkgs as links relative to the file self; packages
+// without a file of their own are listed as plain text.
+func packageLinks(out *outline.Outline, self string, pkgs []string) string {
+	if len(pkgs) == 0 {
+		return "none"
+	}
+	sorted := append([]string(nil), pkgs...)
+	sort.Strings(sorted)
+	links := make([]string, len(sorted))
+	for i, pkg := range sorted {
+		if _, ok := out.Packages[pkg]; ok {
+			links[i] = fmt.Sprintf("[%s](%s)", packageLabel(pkg), relLink(self, PackageFile(pkg)))
+		} else {
+			links[i] = packageLabel(pkg)
+		}
+	}
+	return strings.Join(links, ", ")
+}
+
+// removeStalePackageFiles deletes markdown files under dir/packages that
+// codebrev wrote earlier (they start with a codebrev header) but did not
+// write this time, then any directories left empty.
+func removeStalePackageFiles(dir string, written map[string]string) error {
+	root := filepath.Join(dir, SplitPackagesDir)
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, p)
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || filepath.Ext(p) != ".md" {
+			return err
+		}
+		if _, ok := written[filepath.ToSlash(rel)]; ok {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if _, _, ok := SplitHeader(content); ok {
+			return os.Remove(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Deepest first; os.Remove fails on, and so keeps, non-empty directories.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
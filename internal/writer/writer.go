@@ -2,11 +2,16 @@ package writer
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"go/doc"
+	"io"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/jasonwillschiu/codebrev/internal/layering"
 	"github.com/jasonwillschiu/codebrev/internal/mermaid"
 	"github.com/jasonwillschiu/codebrev/internal/outline"
 )
@@ -42,20 +47,87 @@ func WriteOutlineToFile(out *outline.Outline) error {
 	return WriteOutlineToFileWithPath(out, "codebrev.md")
 }
 
+// Section names accepted in Options.Sections, in output order.
+const (
+	SectionDiagnostics   = "diagnostics"
+	SectionDependencyMap = "dependency-map"
+	SectionContracts     = "contracts"
+	SectionInterfaces    = "interfaces"
+	SectionArchitecture  = "architecture"
+	SectionCycles        = "cycles"
+	SectionGuidelines    = "guidelines"
+	SectionChangeImpact  = "change-impact"
+	SectionPublicAPI     = "public-api"
+	SectionReverseDeps   = "reverse-deps"
+	SectionFiles         = "files"
+)
+
+// SectionNames lists every section in output order.
+var SectionNames = []string{
+	SectionDiagnostics,
+	SectionDependencyMap,
+	SectionContracts,
+	SectionInterfaces,
+	SectionArchitecture,
+	SectionCycles,
+	SectionGuidelines,
+	SectionChangeImpact,
+	SectionPublicAPI,
+	SectionReverseDeps,
+	SectionFiles,
+}
+
+// IsSection reports whether name is a known section.
+func IsSection(name string) bool {
+	return slices.Contains(SectionNames, name)
+}
+
+// Options controls what the markdown outline contains.
+type Options struct {
+	// Sections limits the outline to these sections; empty means all.
+	// Sections are always written in SectionNames order.
+	Sections []string
+	// MaxTokens, when positive, drops the least important sections and file
+	// blocks until the estimated size fits (see EstimateTokens).
+	MaxTokens int
+	// ArchRules are the layering rules reported in the architecture
+	// section, which is omitted when there are none.
+	ArchRules []layering.Rule
+	// Version is recorded in the header comment; empty is written as "dev".
+	Version string
+	// Docs is how much of each doc comment the file sections show:
+	// DocsFirst (the default when empty), DocsFull or DocsNone.
+	Docs string
+}
+
+// Doc comment modes accepted in Options.Docs.
+const (
+	DocsFirst = "first" // first sentence
+	DocsFull  = "full"  // whole comment
+	DocsNone  = "none"
+)
+
+// IsDocsMode reports whether mode is a valid Options.Docs value.
+func IsDocsMode(mode string) bool {
+	return mode == "" || mode == DocsFirst || mode == DocsFull || mode == DocsNone
+}
+
+func (o Options) wants(section string) bool {
+	return len(o.Sections) == 0 || slices.Contains(o.Sections, section)
+}
+
 // WriteOutlineToFileWithPath writes the outline to a specified file path
 func WriteOutlineToFileWithPath(out *outline.Outline, filePath string) error {
+	return WriteOutlineToFileWithOptions(out, filePath, Options{})
+}
+
+// WriteOutlineToFileWithOptions writes the outline to filePath using opts.
+func WriteOutlineToFileWithOptions(out *outline.Outline, filePath string, opts Options) error {
 	file, err := os.Create(filePath)
 	if err != nil {
 		return err
 	}
-	sw := &safeWriter{w: bufio.NewWriter(file)}
-	writeOutline(sw, out)
-
-	if err := sw.err; err != nil {
-		_ = file.Close()
-		return err
-	}
-	if err := sw.w.Flush(); err != nil {
+	if err := WriteOutlineWithOptions(file, out, opts); err != nil {
 		_ = file.Close()
 		return err
 	}
@@ -63,38 +135,130 @@ func WriteOutlineToFileWithPath(out *outline.Outline, filePath string) error {
 		return err
 	}
 
-	fmt.Printf("Code outline written to %s\n", filePath)
+	fmt.Fprintf(os.Stderr, "Code outline written to %s\n", filePath)
 	return nil
 }
 
-func writeOutline(w *safeWriter, out *outline.Outline) {
-	w.Println("# Code Structure Outline")
-	w.Println("")
-	w.Println("This file provides an overview of available functions and types per file for LLM context.")
-	w.Println("")
+// WriteOutline writes the full markdown outline to w.
+func WriteOutline(w io.Writer, out *outline.Outline) error {
+	return WriteOutlineWithOptions(w, out, Options{})
+}
+
+// WriteOutlineWithOptions writes the markdown outline to w using opts.
+func WriteOutlineWithOptions(w io.Writer, out *outline.Outline, opts Options) error {
+	return writeTo(w, func(sw *safeWriter) { writeOutline(sw, out, opts) })
+}
+
+// WriteFileSection writes the markdown section for a single file, as it
+// appears in the full outline. docs is an Options.Docs mode.
+func WriteFileSection(w io.Writer, out *outline.Outline, path, docs string) error {
+	if _, ok := out.Files[path]; !ok {
+		return fmt.Errorf("file not in outline: %s", path)
+	}
+	return writeTo(w, func(sw *safeWriter) { writeFileSection(sw, out, path, docs) })
+}
+
+// WriteContracts writes the contracts section (tagged structs and routes).
+func WriteContracts(w io.Writer, out *outline.Outline) error {
+	return writeTo(w, func(sw *safeWriter) { writeContracts(sw, out) })
+}
+
+// render runs write against an in-memory buffer and returns the text.
+func render(write func(sw *safeWriter)) string {
+	var buf bytes.Buffer
+	// Writes to a bytes.Buffer cannot fail.
+	_ = writeTo(&buf, write)
+	return buf.String()
+}
+
+func writeTo(w io.Writer, write func(sw *safeWriter)) error {
+	sw := &safeWriter{w: bufio.NewWriter(w)}
+	write(sw)
+	if sw.err != nil {
+		return sw.err
+	}
+	return sw.w.Flush()
+}
+
+func writeOutline(w *safeWriter, out *outline.Outline, opts Options) {
+	blocks := outlineBlocks(out, opts)
+	if opts.MaxTokens > 0 {
+		blocks = fitBudget(blocks, opts.MaxTokens)
+	}
+	var body strings.Builder
+	for _, b := range blocks {
+		body.WriteString(b.text)
+	}
+	w.Print(withHeader(body.String(), opts.Version))
+}
+
+// outlineBlocks renders the outline as a sequence of blocks in document
+// order: the header, each enabled section, then one block per file.
+func outlineBlocks(out *outline.Outline, opts Options) []block {
+	blocks := []block{{
+		name:     "header",
+		priority: priorityHeader,
+		text: render(func(w *safeWriter) {
+			w.Println("# Code Structure Outline")
+			w.Println("")
+			w.Println("This file provides an overview of available functions and types per file for LLM context.")
+			w.Println("")
+		}),
+	}}
+	addSection := func(name string, priority int, write func(w *safeWriter)) {
+		if opts.wants(name) {
+			blocks = append(blocks, block{name: name, priority: priority, text: render(write)})
+		}
+	}
+
+	// Scan problems first, so readers know what may be missing
+	if len(out.Diagnostics) > 0 {
+		addSection(SectionDiagnostics, prioritySummary, func(w *safeWriter) { writeDiagnostics(w, out) })
+	}
 
 	// Generate and include mermaid dependency map (single combined diagram)
-	w.Println("## Dependency Map (LLM + Human Context)")
-	w.Println("")
-	w.Println("This diagram combines package-level dependencies and key files into a single readable map.")
-	w.Println("Note: External imports are intentionally omitted here; check go.mod (or module go.mod files in go.work workspaces) for dependencies.")
-	w.Println("")
-	w.Print(mermaid.GenerateUnifiedDependencyMap(out))
-	w.Println("")
+	addSection(SectionDependencyMap, priorityDiagram, func(w *safeWriter) {
+		w.Println("## Dependency Map (LLM + Human Context)")
+		w.Println("")
+		w.Println("This diagram combines package-level dependencies and key files into a single readable map.")
+		w.Println("Note: External imports are intentionally omitted here; check go.mod (or module go.mod files in go.work workspaces) for dependencies.")
+		w.Println("")
+		w.Print(mermaid.GenerateUnifiedDependencyMap(out))
+		w.Println("")
+	})
+
+	addSection(SectionContracts, prioritySummary, func(w *safeWriter) { writeContracts(w, out) })
+
+	// Interface implementations, only when there are any
+	if ifaces := implementedInterfaces(out); len(ifaces) > 0 {
+		addSection(SectionInterfaces, prioritySummary, func(w *safeWriter) { writeInterfaces(w, out, ifaces) })
+	}
+
+	if len(opts.ArchRules) > 0 {
+		addSection(SectionArchitecture, prioritySummary, func(w *safeWriter) { writeArchitecture(w, out, opts.ArchRules) })
+	}
 
-	writeContracts(w, out)
+	// Dependency cycles, only when there are any
+	fileCycles, packageCycles := out.FileCycles(), out.PackageCycles()
+	if len(fileCycles) > 0 || len(packageCycles) > 0 {
+		addSection(SectionCycles, prioritySummary, func(w *safeWriter) { writeCycles(w, fileCycles, packageCycles) })
+	}
 
 	// Write AI Agent Guidance
-	writeAIAgentGuidance(w, out)
+	addSection(SectionGuidelines, priorityContext, func(w *safeWriter) { writeAIAgentGuidance(w, out) })
 
 	// Write Change Impact Analysis
-	writeChangeImpactAnalysis(w, out)
+	addSection(SectionChangeImpact, prioritySummary, func(w *safeWriter) { writeChangeImpactAnalysis(w, out) })
 
 	// Write Public API Surface
-	writePublicAPISurface(w, out)
+	addSection(SectionPublicAPI, prioritySummary, func(w *safeWriter) { writePublicAPISurface(w, out) })
 
 	// Write Reverse Dependencies
-	writeReverseDependencies(w, out)
+	addSection(SectionReverseDeps, priorityContext, func(w *safeWriter) { writeReverseDependencies(w, out) })
+
+	if !opts.wants(SectionFiles) {
+		return blocks
+	}
 
 	// Sort file paths for consistent output
 	var filePaths []string
@@ -105,63 +269,160 @@ func writeOutline(w *safeWriter, out *outline.Outline) {
 
 	// Write file-by-file breakdown
 	for _, path := range filePaths {
-		fileInfo := out.Files[path]
-		w.Printf("## %s\n", path)
+		risk := out.CalculateChangeImpact(path).RiskLevel
+		blocks = append(blocks, block{
+			name:     path,
+			isFile:   true,
+			risk:     risk,
+			priority: filePriority(risk),
+			text:     render(func(w *safeWriter) { writeFileSection(w, out, path, opts.Docs) }),
+		})
+	}
+	return blocks
+}
+
+func writeFileSection(w *safeWriter, out *outline.Outline, path, docs string) {
+	fileInfo := out.Files[path]
+	w.Printf("## %s\n", path)
+	w.Println("")
+
+	if fileInfo.Degraded {
+		w.Println("> Degraded: this file has syntax errors and was outlined from a partial parse; some declarations may be missing.")
 		w.Println("")
+	}
 
-		// Functions available in this file
-		if len(fileInfo.Functions) > 0 {
-			// Sort functions by name
-			sort.Slice(fileInfo.Functions, func(i, j int) bool {
-				return fileInfo.Functions[i].Name < fileInfo.Functions[j].Name
-			})
-			w.Println("### Functions")
-			for _, f := range fileInfo.Functions {
-				params := strings.Join(f.Params, ", ")
-				if f.ReturnType != "" {
-					w.Printf("- %s(%s) -> %s\n", f.Name, params, f.ReturnType)
-				} else {
-					w.Printf("- %s(%s)\n", f.Name, params)
-				}
+	// Functions available in this file
+	if len(fileInfo.Functions) > 0 {
+		// Sort functions by name
+		sort.Slice(fileInfo.Functions, func(i, j int) bool {
+			return fileInfo.Functions[i].Name < fileInfo.Functions[j].Name
+		})
+		w.Println("### Functions")
+		for _, f := range fileInfo.Functions {
+			params := strings.Join(f.Params, ", ")
+			w.Printf("- %s%s(%s)", f.Name, typeParams(f.TypeParams), params)
+			if f.ReturnType != "" {
+				w.Printf(" -> %s", f.ReturnType)
+			}
+			if f.Degraded {
+				w.Print(" (degraded)")
 			}
 			w.Println("")
+			writeDoc(w, f.Doc, docs)
 		}
+		w.Println("")
+	}
 
-		// Types/Structs/Classes available in this file
-		if len(fileInfo.Types) > 0 {
-			sort.Strings(fileInfo.Types)
-			w.Println("### Types")
-			for _, t := range fileInfo.Types {
-				w.Printf("- %s", t)
-				if ti, exists := out.Types[t]; exists {
-					if len(ti.Methods) > 0 {
-						w.Printf(" (methods: %s)", strings.Join(ti.Methods, ", "))
-					}
-					if len(ti.Fields) > 0 {
-						w.Printf(" (fields: %s)", strings.Join(ti.Fields, ", "))
-					}
-					if len(ti.ContractKeys) > 0 {
-						w.Printf(" (contracts: %s)", strings.Join(ti.ContractKeys, ", "))
+	// Types/Structs/Classes available in this file
+	if len(fileInfo.Types) > 0 {
+		sort.Strings(fileInfo.Types)
+		w.Println("### Types")
+		var names func(string) string // built on first use; most files need none
+		typeList := func(keys []string) string {
+			if names == nil {
+				names = out.TypeNames()
+			}
+			return typeNameList(keys, names)
+		}
+		for _, t := range fileInfo.Types {
+			w.Printf("- %s", t)
+			ti := out.FileType(fileInfo, t)
+			if ti != nil {
+				w.Print(typeParams(ti.TypeParams))
+				if len(ti.Methods) > 0 {
+					w.Printf(" (methods: %s)", strings.Join(ti.Methods, ", "))
+				}
+				if len(ti.Implements) > 0 {
+					w.Printf(" (implements: %s)", typeList(ti.Implements))
+				}
+				if ti.Kind == outline.KindInterface {
+					key := outline.TypeKey(outline.TypeScope(fileInfo), t)
+					if implementers := out.Implementers(key); len(implementers) > 0 {
+						w.Printf(" (implemented by: %s)", typeList(implementers))
 					}
 				}
-				w.Println("")
+				if len(ti.EnumValues) > 0 {
+					w.Printf(" (enum: %s)", strings.Join(ti.EnumValues, ", "))
+				}
+				if len(ti.Fields) > 0 {
+					w.Printf(" (fields: %s)", strings.Join(ti.Fields, ", "))
+				}
+				if len(ti.ContractKeys) > 0 {
+					w.Printf(" (contracts: %s)", strings.Join(ti.ContractKeys, ", "))
+				}
 			}
 			w.Println("")
+			if ti != nil {
+				writeDoc(w, ti.Doc, docs)
+			}
 		}
+		w.Println("")
+	}
 
-		// Routes extracted from this file (best-effort).
-		if len(fileInfo.Routes) > 0 {
-			sort.Strings(fileInfo.Routes)
-			w.Println("### Routes")
-			for _, r := range fileInfo.Routes {
-				w.Printf("- %s\n", r)
+	// Exported constants and variables, in declaration order so enum
+	// values stay in sequence.
+	if len(fileInfo.Values) > 0 {
+		w.Println("### Constants and Variables")
+		for _, v := range fileInfo.Values {
+			line := v.Kind + " " + v.Name
+			if v.Type != "" {
+				line += " " + v.Type
 			}
-			w.Println("")
+			if v.Value != "" {
+				line += " = " + v.Value
+			}
+			w.Printf("- %s\n", line)
+			writeDoc(w, v.Doc, docs)
 		}
+		w.Println("")
+	}
 
-		w.Println("---")
+	// Routes extracted from this file (best-effort).
+	if len(fileInfo.Routes) > 0 {
+		sort.Strings(fileInfo.Routes)
+		w.Println("### Routes")
+		for _, r := range fileInfo.Routes {
+			w.Printf("- %s\n", r)
+		}
 		w.Println("")
 	}
+
+	w.Println("---")
+	w.Println("")
+}
+
+// writeDoc writes a declaration's doc comment, as much as the docs mode
+// asks for, as indented lines continuing its list item.
+func writeDoc(w *safeWriter, text, docs string) {
+	if text == "" || docs == DocsNone {
+		return
+	}
+	if docs != DocsFull {
+		text = new(doc.Package).Synopsis(text)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimRight(line, " \t"); line != "" {
+			w.Printf("  %s\n", line)
+		}
+	}
+}
+
+// typeNameList renders type keys for display, comma-separated.
+func typeNameList(keys []string, names func(string) string) string {
+	rendered := make([]string, len(keys))
+	for i, key := range keys {
+		rendered[i] = names(key)
+	}
+	return strings.Join(rendered, ", ")
+}
+
+// typeParams renders a type parameter list as "[T any, K comparable]", or
+// "" when there is none.
+func typeParams(params []string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(params, ", ") + "]"
 }
 
 func writeContracts(writer *safeWriter, out *outline.Outline) {
@@ -174,23 +435,24 @@ func writeContracts(writer *safeWriter, out *outline.Outline) {
 
 	// Tagged structs / DTO-like contracts.
 	var contractTypes []string
-	for name, ti := range out.Types {
-		if name == "" || ti == nil || len(ti.ContractKeys) == 0 {
+	for key, ti := range out.Types {
+		if key == "" || ti == nil || len(ti.ContractKeys) == 0 {
 			continue
 		}
-		contractTypes = append(contractTypes, name)
+		contractTypes = append(contractTypes, key)
 	}
-	sort.Strings(contractTypes)
+	outline.SortTypeKeys(contractTypes)
 
 	if len(contractTypes) > 0 {
+		names := out.TypeNames()
 		writer.Println("### Tagged Structs")
-		for _, name := range contractTypes {
-			ti := out.Types[name]
+		for _, key := range contractTypes {
+			ti := out.Types[key]
 			keys := append([]string(nil), ti.ContractKeys...)
 			sort.Strings(keys)
-			writer.Printf("- %s (keys: %s)", name, strings.Join(keys, ", "))
+			writer.Printf("- %s (keys: %s)", names(key), strings.Join(keys, ", "))
 
-			usedBy := out.TypeUsage[name]
+			usedBy := out.TypeUsage[key]
 			if len(usedBy) > 0 {
 				sort.Strings(usedBy)
 				if len(usedBy) > 10 {
@@ -244,14 +506,14 @@ func writeAIAgentGuidance(writer *safeWriter, out *outline.Outline) {
 	writer.Println("### High-risk changes:")
 	// Find core types with many dependents
 	var highRiskTypes []string
-	for typeName, usages := range out.TypeUsage {
+	for key, usages := range out.TypeUsage {
 		if len(usages) > 5 {
-			highRiskTypes = append(highRiskTypes, typeName)
+			highRiskTypes = append(highRiskTypes, key)
 		}
 	}
 	if len(highRiskTypes) > 0 {
-		sort.Strings(highRiskTypes)
-		writer.Printf("- Modifying core types: %s\n", strings.Join(highRiskTypes, ", "))
+		outline.SortTypeKeys(highRiskTypes)
+		writer.Printf("- Modifying core types: %s\n", typeNameList(highRiskTypes, out.TypeNames()))
 	}
 	writer.Println("- Changing package structure")
 	writer.Println("- Removing public APIs")
@@ -371,12 +633,14 @@ func writePublicAPISurface(writer *safeWriter, out *outline.Outline) {
 
 	var filePaths []string
 	for path := range out.PublicAPIs {
-		if len(out.PublicAPIs[path]) > 0 {
-			filePaths = append(filePaths, path)
-		}
+		filePaths = append(filePaths, path)
 	}
 	sort.Strings(filePaths)
+	writePublicAPIs(writer, out, filePaths)
+}
 
+// writePublicAPIs lists the public API of each of filePaths that has one.
+func writePublicAPIs(writer *safeWriter, out *outline.Outline, filePaths []string) {
 	for _, path := range filePaths {
 		apis := out.PublicAPIs[path]
 		if len(apis) > 0 {
@@ -399,12 +663,14 @@ func writeReverseDependencies(writer *safeWriter, out *outline.Outline) {
 
 	var filePaths []string
 	for path := range out.ReverseDeps {
-		if len(out.ReverseDeps[path]) > 0 {
-			filePaths = append(filePaths, path)
-		}
+		filePaths = append(filePaths, path)
 	}
 	sort.Strings(filePaths)
+	writeReverseDepsOf(writer, out, filePaths)
+}
 
+// writeReverseDepsOf lists the dependents of each of filePaths that has any.
+func writeReverseDepsOf(writer *safeWriter, out *outline.Outline, filePaths []string) {
 	for _, path := range filePaths {
 		deps := out.ReverseDeps[path]
 		if len(deps) > 0 {
@@ -1,14 +1,23 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/jasonwillschiu/codebrev/internal/config"
 	"github.com/jasonwillschiu/codebrev/internal/outline"
 	"github.com/jasonwillschiu/codebrev/internal/parser"
+	"github.com/jasonwillschiu/codebrev/internal/watch"
 	"github.com/jasonwillschiu/codebrev/internal/writer"
 )
 
@@ -20,12 +29,35 @@ var (
 )
 
 func main() {
+	// Subcommands take precedence over the default generate mode.
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+
 	// Command line flags
 	var (
 		showVersion = flag.Bool("version", false, "Show version information")
 		showHelp    = flag.Bool("help", false, "Show help information")
-		outputFile  = flag.String("output", "", "Output file path (defaults to 'codebrev.md' in target directory)")
+		outputFile  = flag.String("output", "", "Output file path (defaults to 'codebrev.md' in target directory); - writes to stdout")
+		format      = flag.String("format", formatMarkdown, "Output format: markdown or json")
+		fromJSON    = flag.String("from-json", "", "Render from a previously generated JSON outline instead of parsing")
+		configFile  = flag.String("config", "", "Config file (defaults to .codebrev.yaml in the target directory, if present)")
+		cacheDir    = flag.String("cache-dir", "", "Reuse per-file parse results stored in this directory (e.g. .codebrev-cache)")
+		workers     = flag.Int("workers", runtime.NumCPU(), "Number of files parsed concurrently")
+		maxTokens   = flag.Int("max-tokens", 0, "Trim the markdown outline to about N tokens, keeping the most important content")
+		watchMode   = flag.Bool("watch", false, "Keep running and regenerate the output when source files change")
+		debounce    = flag.Duration("debounce", watch.DefaultDebounce, "Quiet period after the last change before regenerating (with --watch)")
+		strict      = flag.Bool("strict", false, "Exit with an error if any file could not be read or parsed")
+		filesFrom   = flag.String("files-from", "", "Parse only the newline-separated paths listed in this file (- reads stdin)")
+		analysis    = flag.String("analysis", "", "Go analysis mode: syntax (default) or types (type-checked call graph)")
+		docs        = flag.String("docs", "", "Doc comments in the outline: first (sentence, default), full or none")
+		split       = flag.Bool("split", false, "Write an index plus one markdown file per package to a codebrev/ directory")
 	)
+	var includes, excludes stringList
+	flag.Var(&includes, "include", "Only parse files matching this gitignore-style `pattern` (repeatable)")
+	flag.Var(&excludes, "exclude", "Skip files and directories matching this gitignore-style `pattern` (repeatable)")
 	flag.Parse()
 
 	// Handle version flag
@@ -44,11 +76,71 @@ func main() {
 		return
 	}
 
+	if *format != formatMarkdown && *format != formatJSON {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (expected %q or %q)\n", *format, formatMarkdown, formatJSON)
+		os.Exit(2)
+	}
+	if *maxTokens < 0 || (*maxTokens > 0 && *format != formatMarkdown) {
+		fmt.Fprintln(os.Stderr, "Error: --max-tokens must be positive and only applies to markdown output")
+		os.Exit(2)
+	}
+	if !parser.IsAnalysis(*analysis) {
+		fmt.Fprintf(os.Stderr, "Error: unknown analysis mode %q (expected %q or %q)\n", *analysis, parser.AnalysisSyntax, parser.AnalysisTypes)
+		os.Exit(2)
+	}
+	if !writer.IsDocsMode(*docs) {
+		fmt.Fprintf(os.Stderr, "Error: unknown docs mode %q (expected %q, %q or %q)\n", *docs, writer.DocsFirst, writer.DocsFull, writer.DocsNone)
+		os.Exit(2)
+	}
+	if *split && (*format != formatMarkdown || *maxTokens > 0) {
+		fmt.Fprintln(os.Stderr, "Error: --split only applies to markdown output and cannot be combined with --max-tokens")
+		os.Exit(2)
+	}
+	if *split && *outputFile == stdioPath {
+		fmt.Fprintln(os.Stderr, "Error: --split writes a directory and cannot write to stdout")
+		os.Exit(2)
+	}
+	if *watchMode && (*fromJSON != "" || *filesFrom != "" || *outputFile == stdioPath) {
+		fmt.Fprintln(os.Stderr, "Error: --watch cannot be combined with --from-json, --files-from or --output -")
+		os.Exit(2)
+	}
+	if *fromJSON != "" && *filesFrom != "" {
+		fmt.Fprintln(os.Stderr, "Error: --files-from cannot be combined with --from-json")
+		os.Exit(2)
+	}
+
 	// Get remaining arguments (directory path)
 	args := flag.Args()
 
 	// Run CLI mode
-	runCLIMode(args, *outputFile)
+	runCLIMode(args, cliOptions{
+		OutputFile: *outputFile,
+		Format:     *format,
+		FromJSON:   *fromJSON,
+		ConfigFile: *configFile,
+		Include:    includes,
+		Exclude:    excludes,
+		CacheDir:   *cacheDir,
+		Workers:    *workers,
+		MaxTokens:  *maxTokens,
+		Watch:      *watchMode,
+		Debounce:   *debounce,
+		Strict:     *strict,
+		Split:      *split,
+		FilesFrom:  *filesFrom,
+		Analysis:   *analysis,
+		Docs:       *docs,
+	})
+}
+
+// subcommands maps `codebrev <name>` to its entry point. Each returns the
+// process exit code.
+var subcommands = map[string]func(args []string) int{
+	"check":     runCheckCommand,
+	"impact":    runImpactCommand,
+	"lint-arch": runLintArchCommand,
+	"map":       runMapCommand,
+	"mcp":       runMCPCommand,
 }
 
 func showHelpMessage() {
@@ -56,80 +148,389 @@ func showHelpMessage() {
 	fmt.Println("")
 	fmt.Println("USAGE:")
 	fmt.Println("  codebrev [OPTIONS] [DIRECTORY]")
+	fmt.Println("  codebrev COMMAND [OPTIONS] [ARGS]")
 	fmt.Println("")
 	fmt.Println("DESCRIPTION:")
 	fmt.Println("  Generate a codebrev.md file containing code structure outline for the specified directory.")
 	fmt.Println("  If no directory is specified, defaults to current directory.")
 	fmt.Println("")
+	fmt.Println("COMMANDS:")
+	fmt.Println("  check [DIRECTORY] Exit non-zero if the committed codebrev.md is out of date (for CI)")
+	fmt.Println("  impact PATH...    Show dependents, dependency paths and risk for files or packages")
+	fmt.Println("  lint-arch [DIR]   Exit non-zero if package dependencies break the architecture rules in the config")
+	fmt.Println("  map --seed PATH   Treasure map: bounded graph of what surrounds the files you plan to change")
+	fmt.Println("  mcp [DIRECTORY]   Serve codebrev tools over the Model Context Protocol (stdio)")
+	fmt.Println("")
+	fmt.Println("  Run 'codebrev COMMAND --help' for command options.")
+	fmt.Println("")
 	fmt.Println("OPTIONS:")
 	fmt.Println("  --version         Show version information")
 	fmt.Println("  --help            Show this help message")
-	fmt.Println("  --output FILE     Output file path (defaults to 'codebrev.md' in target directory)")
+	fmt.Println("  --output FILE     Output file path (defaults to 'codebrev.md' in target directory; - for stdout)")
+	fmt.Println("  --format FORMAT   Output format: markdown (default) or json")
+	fmt.Println("  --from-json FILE  Render from a saved JSON outline instead of parsing DIRECTORY")
+	fmt.Println("  --files-from FILE Parse only the newline-separated paths in FILE instead of walking (- for stdin)")
+	fmt.Println("  --include PAT     Only parse files matching a gitignore-style pattern (repeatable)")
+	fmt.Println("  --exclude PAT     Skip files and directories matching a gitignore-style pattern (repeatable)")
+	fmt.Println("  --config FILE     Config file (default: .codebrev.yaml in DIRECTORY, if present)")
+	fmt.Println("  --cache-dir DIR   Reuse per-file parse results from DIR; unchanged files are not re-parsed")
+	fmt.Println("  --workers N       Number of files parsed concurrently (default: number of CPUs)")
+	fmt.Println("  --max-tokens N    Trim the markdown outline to about N tokens (high-risk files, contracts and public API first)")
+	fmt.Println("  --watch           Keep running and regenerate the output when source files change")
+	fmt.Println("  --debounce DUR    Quiet period before regenerating in watch mode (default 500ms)")
+	fmt.Println("  --docs MODE       Doc comments per function and type: first sentence (default), full or none")
+	fmt.Println("  --analysis MODE   Go analysis: syntax (default) or types (resolve calls and types with go/types)")
+	fmt.Println("  --strict          Fail if any file could not be read or parsed (see the Diagnostics section)")
+	fmt.Println("  --split           Write codebrev/index.md plus one file per package (--output sets the directory)")
 	fmt.Println("")
 	fmt.Println("EXAMPLES:")
 	fmt.Println("  codebrev .                    # Generate codebrev.md for current directory")
 	fmt.Println("  codebrev /path/to/project     # Generate codebrev.md for specified directory")
 	fmt.Println("  codebrev --output custom.md . # Generate with custom output filename")
+	fmt.Println("  codebrev --format json .      # Generate codebrev.json for scripts and agents")
+	fmt.Println("  codebrev --watch .            # Keep codebrev.md fresh while you edit")
+	fmt.Println("  codebrev --output - . | less  # Stream the outline to stdout")
+	fmt.Println("  git diff --name-only main | codebrev --files-from - --output - .")
+	fmt.Println("  codebrev --split .            # Write codebrev/index.md and codebrev/packages/*.md")
+	fmt.Println("  codebrev --include 'services/billing/**' --exclude '**/generated/**' .")
+	fmt.Println("  codebrev --cache-dir .codebrev-cache .               # Only re-parse changed files")
+	fmt.Println("  codebrev --from-json codebrev.json --output view.md  # Re-render a saved outline")
+	fmt.Println("  codebrev impact internal/outline/types.go            # Blast radius of one file")
+	fmt.Println("  codebrev check .                                     # Fail CI when codebrev.md is stale")
+	fmt.Println("  codebrev lint-arch .                                 # Enforce package layering rules")
+	fmt.Println("  codebrev map --seed internal/parser/go.go            # Treasure map for a planned change")
 }
 
-func runCLIMode(args []string, outputFile string) {
+// cliOptions holds the flags that shape a generate run.
+type cliOptions struct {
+	OutputFile string
+	Format     string
+	FromJSON   string // load this JSON outline instead of parsing the directory
+	ConfigFile string // explicit --config path; empty means discover .codebrev.yaml
+	Config     *config.Config
+	Include    []string // --include patterns; replace the config's include list
+	Exclude    []string // --exclude patterns; added after the config's exclude list
+	CacheDir   string   // per-file parse cache; empty disables caching
+	Workers    int      // parse concurrency; <= 0 means one per CPU
+	MaxTokens  int      // markdown token budget; 0 means unlimited
+	Watch      bool
+	Debounce   time.Duration
+	Strict     bool     // fail when the scan reports any error diagnostic
+	Split      bool     // OutputFile is a directory for the index and per-package files
+	FilesFrom  string   // file listing the paths to parse; "-" is stdin
+	Files      []string // paths read from FilesFrom; nil means walk the directory
+	Analysis   string   // Go analysis mode; overrides the config's analysis
+	Docs       string   // doc comment mode; overrides the config's docs
+}
+
+func runCLIMode(args []string, opts cliOptions) {
 	// Default to current directory if no directory specified
 	directoryPath := "."
 	if len(args) > 0 {
 		directoryPath = args[0]
 	}
 
-	// Set default output file if not specified
-	if outputFile == "" {
-		outputFile = filepath.Join(directoryPath, "codebrev.md")
-	}
-
-	fmt.Printf("Generating code context for: %s\n", directoryPath)
-	fmt.Printf("Output file: %s\n", outputFile)
-
 	// Check if directory exists
 	if _, err := os.Stat(directoryPath); os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error: directory does not exist: %s\n", directoryPath)
 		os.Exit(1)
 	}
 
+	cfg, err := loadProjectConfig(directoryPath, opts.ConfigFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if opts.FilesFrom != "" {
+		opts.Files, err = readFileList(opts.FilesFrom)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: reading --files-from: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	flagOpts := opts
+	opts = opts.withConfig(directoryPath, cfg)
+	outputFile := opts.OutputFile
+
+	// Status goes to stderr so stdout carries only the outline with --output -.
+	if opts.FromJSON != "" {
+		fmt.Fprintf(os.Stderr, "Loading outline from: %s\n", opts.FromJSON)
+	} else if opts.Files != nil {
+		fmt.Fprintf(os.Stderr, "Generating code context for %d listed path(s) in: %s\n", len(opts.Files), directoryPath)
+	} else {
+		fmt.Fprintf(os.Stderr, "Generating code context for: %s\n", directoryPath)
+	}
+	if cfg != nil {
+		fmt.Fprintf(os.Stderr, "Config file: %s\n", cfg.Path)
+	}
+	switch {
+	case opts.Split:
+		fmt.Fprintf(os.Stderr, "Output directory: %s\n", outputFile)
+	case outputFile == stdioPath:
+		fmt.Fprintln(os.Stderr, "Output: stdout")
+	default:
+		fmt.Fprintf(os.Stderr, "Output file: %s\n", outputFile)
+	}
+
 	// Generate the code context
-	err := generateCodeContext(directoryPath, outputFile)
+	err = generateCodeContext(directoryPath, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating code context: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Get file size for reporting
-	fileInfo, err := os.Stat(outputFile)
-	var fileSize int64
-	if err == nil {
-		fileSize = fileInfo.Size()
+	fmt.Fprintf(os.Stderr, "Successfully generated code context outline\n")
+	if !opts.Split && outputFile != stdioPath {
+		// Get file size for reporting
+		fileInfo, err := os.Stat(outputFile)
+		var fileSize int64
+		if err == nil {
+			fileSize = fileInfo.Size()
+		}
+		fmt.Fprintf(os.Stderr, "File: %s (%d bytes)\n", outputFile, fileSize)
+	}
+
+	if opts.Watch {
+		watchAndRegenerate(directoryPath, flagOpts, opts)
 	}
+}
 
-	fmt.Printf("Successfully generated code context outline\n")
-	fmt.Printf("File: %s (%d bytes)\n", outputFile, fileSize)
+// withConfig returns opts with cfg applied: the config itself, and the
+// output path it or the defaults give when --output is not set.
+func (opts cliOptions) withConfig(directoryPath string, cfg *config.Config) cliOptions {
+	opts.Config = cfg
+	if opts.OutputFile == "" {
+		opts.OutputFile = cfg.OutputPath()
+		switch {
+		case opts.OutputFile == "":
+		case opts.Split:
+			opts.OutputFile = filepath.Join(filepath.Dir(opts.OutputFile), splitDirName)
+		case opts.Format == formatJSON:
+			// The configured output is the markdown outline; JSON goes next to it.
+			opts.OutputFile = cfg.JSONOutputPath()
+		}
+	}
+	if opts.OutputFile == "" {
+		opts.OutputFile = filepath.Join(directoryPath, defaultOutputName(opts.Format))
+		if opts.Split {
+			opts.OutputFile = filepath.Join(directoryPath, splitDirName)
+		}
+	}
+	return opts
+}
+
+// watchAndRegenerate blocks until interrupted, regenerating the output each
+// time the scanned tree or the config file changes and then settles. The
+// config is reloaded before each regeneration; flagOpts are the options
+// before any config was applied.
+func watchAndRegenerate(directoryPath string, flagOpts, opts cliOptions) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	absRoot, err := filepath.Abs(directoryPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error watching %s: %v\n", directoryPath, err)
+		os.Exit(1)
+	}
+	configFile := flagOpts.ConfigFile
+	if configFile == "" {
+		configFile = config.DefaultPath(directoryPath)
+	}
+
+	fmt.Fprintf(os.Stderr, "Watching %s for changes (Ctrl+C to stop)\n", directoryPath)
+	watchOpts := watch.Options{
+		Debounce: opts.Debounce,
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "Error generating code context: %v\n", err)
+		},
+		ConfigFile: configFile,
+		Filter: func() *parser.Filter {
+			return parser.NewFilter(absRoot, opts.parserOptions())
+		},
+	}
+	err = watch.Run(ctx, directoryPath, watchOpts, func() error {
+		fmt.Fprintf(os.Stderr, "[%s] Change detected, regenerating\n", time.Now().Format("15:04:05"))
+		// An invalid config keeps the last good one until it is fixed.
+		cfg, err := loadProjectConfig(directoryPath, flagOpts.ConfigFile)
+		if err != nil {
+			return fmt.Errorf("invalid config: %v", err)
+		}
+		opts = flagOpts.withConfig(directoryPath, cfg)
+		return generateCodeContext(directoryPath, opts)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error watching %s: %v\n", directoryPath, err)
+		os.Exit(1)
+	}
 }
 
 // generateCodeContext generates the code context outline using the existing parser and writer
-func generateCodeContext(directoryPath, outputFile string) error {
+func generateCodeContext(directoryPath string, opts cliOptions) error {
+	out, err := loadOutline(directoryPath, opts)
+	if err != nil {
+		return err
+	}
+
+	// Write output to the specified file
+	switch {
+	case opts.Split:
+		err = writer.WriteSplit(out, opts.OutputFile, opts.writerOptions())
+	case opts.OutputFile == stdioPath && opts.Format == formatJSON:
+		err = writer.WriteOutlineJSON(os.Stdout, out, "codebrev "+Version)
+	case opts.OutputFile == stdioPath:
+		err = writer.WriteOutlineWithOptions(os.Stdout, out, opts.writerOptions())
+	case opts.Format == formatJSON:
+		err = writer.WriteOutlineJSONToFileWithPath(out, opts.OutputFile, "codebrev "+Version)
+	default:
+		err = writer.WriteOutlineToFileWithOptions(out, opts.OutputFile, opts.writerOptions())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write outline: %v", err)
+	}
+
+	// The outline is written either way; diagnostics are also listed in it.
+	for _, d := range out.Diagnostics {
+		fmt.Fprintln(os.Stderr, d)
+	}
+	errs := 0
+	for _, d := range out.Diagnostics {
+		if d.Severity == outline.SeverityError {
+			errs++
+		}
+	}
+	if errs > 0 && opts.Strict {
+		return fmt.Errorf("%d error diagnostic(s) reported (--strict)", errs)
+	}
+	return nil
+}
+
+// loadOutline builds the outline for directoryPath, or loads it from
+// opts.FromJSON when set.
+func loadOutline(directoryPath string, opts cliOptions) (*outline.Outline, error) {
+	if opts.FromJSON != "" {
+		out, err := outline.Load(opts.FromJSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load outline: %v", err)
+		}
+		opts.applyRisk(out)
+		return out, nil
+	}
+
 	// Create new outline
 	out := outline.New()
+	opts.applyRisk(out)
 
 	// Process all files in the directory
-	err := parser.ProcessFiles(directoryPath, out)
+	err := parser.ProcessFilesWithOptions(directoryPath, out, opts.parserOptions())
 	if err != nil {
-		return fmt.Errorf("failed to process files: %v", err)
+		return nil, fmt.Errorf("failed to process files: %v", err)
 	}
 
 	// Remove duplicates
 	out.RemoveDuplicates()
+	return out, nil
+}
 
-	// Write output to the specified file
-	err = writer.WriteOutlineToFileWithPath(out, outputFile)
-	if err != nil {
-		return fmt.Errorf("failed to write outline: %v", err)
+// loadProjectConfig reads the config at path, or discovers .codebrev.yaml
+// in the scan root when path is empty. It returns nil when there is none.
+func loadProjectConfig(root, path string) (*config.Config, error) {
+	if path == "" {
+		return config.Discover(root)
 	}
+	return config.Load(path)
+}
 
+func (opts cliOptions) parserOptions() parser.Options {
+	po := opts.Config.ParserOptions()
+	po.CacheDir = opts.CacheDir
+	po.Version = Version
+	po.Workers = opts.Workers
+	po.Files = opts.Files
+	if opts.Analysis != "" {
+		po.Analysis = opts.Analysis
+	}
+	if len(opts.Include) > 0 {
+		po.Include = opts.Include
+	}
+	// Later patterns win, so command-line excludes can override the config.
+	po.Exclude = append(slices.Clip(po.Exclude), opts.Exclude...)
+	return po
+}
+
+func (opts cliOptions) writerOptions() writer.Options {
+	wo := opts.Config.WriterOptions()
+	wo.MaxTokens = opts.MaxTokens
+	wo.Version = Version
+	if opts.Docs != "" {
+		wo.Docs = opts.Docs
+	}
+	return wo
+}
+
+// applyRisk carries configured risk thresholds into the outline.
+func (opts cliOptions) applyRisk(out *outline.Outline) {
+	if cfg := opts.Config; cfg != nil {
+		if cfg.Risk.Medium > 0 {
+			out.RiskMedium = cfg.Risk.Medium
+		}
+		if cfg.Risk.High > 0 {
+			out.RiskHigh = cfg.Risk.High
+		}
+	}
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
 	return nil
 }
+
+// readFileList reads newline-separated paths from path, or from stdin when
+// path is "-". Blank lines are skipped; the result is non-nil even when the
+// list is empty, so an empty list parses nothing.
+func readFileList(path string) ([]string, error) {
+	f := os.Stdin
+	if path != stdioPath {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+	}
+	files := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, scanner.Err()
+}
+
+// stdioPath is the --output value that writes to stdout and the
+// --files-from value that reads stdin.
+const stdioPath = "-"
+
+// splitDirName is the default output directory for --split.
+const splitDirName = "codebrev"
+
+// Output formats accepted by --format.
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+)
+
+func defaultOutputName(format string) string {
+	if format == formatJSON {
+		return "codebrev.json"
+	}
+	return "codebrev.md"
+}
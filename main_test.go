@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadFileList(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"empty", "", []string{}},
+		{"blank lines only", "\n\n  \n", []string{}},
+		{"one per line", "a.go\nb/b.go\n", []string{"a.go", "b/b.go"}},
+		{"no trailing newline", "a.go\nb.go", []string{"a.go", "b.go"}},
+		{"surrounding space and CRLF", "  a.go \r\n\r\nb.go\r\n", []string{"a.go", "b.go"}},
+		{"duplicates kept", "a.go\na.go\n", []string{"a.go", "a.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "files.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readFileList(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil || !slices.Equal(got, tt.want) {
+				t.Errorf("readFileList = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileListMissing(t *testing.T) {
+	if _, err := readFileList(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("readFileList of a missing file succeeded")
+	}
+}